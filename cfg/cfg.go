@@ -35,6 +35,7 @@ type Entry struct {
 	Desc   string
 	Type   string
 	Def    interface{}
+	Secret bool // Def and Value are masked when logged or dumped
 	Source entrySource
 	Value  interface{}
 }
@@ -76,7 +77,46 @@ func NewConfig(name string) *Config {
 // region: dump
 
 func (c *Config) JSON(prefix string, indent string) ([]byte, error) {
-	return json.MarshalIndent(c, prefix, indent)
+	return json.MarshalIndent(c.Redact(), prefix, indent)
 }
 
 // endregion: dumps
+// region: redact
+
+// log.Redactor implementations, secret entries are masked on a copy, the config itself is left alone
+
+func (e Entry) Redact() interface{} {
+	if !e.Secret {
+		return e
+	}
+	if e.Def != nil && e.Def != "" {
+		e.Def = log.Mask
+	}
+	if e.Value != nil && e.Value != "" {
+		e.Value = log.Mask
+	}
+	return e
+}
+
+func (c *Config) Redact() interface{} {
+	r := *c
+	r.Entries = redactEntries(c.Entries)
+	r.FlagSet = make(map[string]*FlagSet, len(c.FlagSet))
+	for k, fs := range c.FlagSet {
+		r.FlagSet[k] = fs.Redact().(*FlagSet)
+	}
+	return &r
+}
+
+func redactEntries(entries map[string]Entry) map[string]Entry {
+	if entries == nil {
+		return nil
+	}
+	r := make(map[string]Entry, len(entries))
+	for k, e := range entries {
+		r[k] = e.Redact().(Entry)
+	}
+	return r
+}
+
+// endregion: redact
@@ -328,7 +328,19 @@ func (fs *FlagSet) MarshalJSON() ([]byte, error) {
 	})
 }
 func (fs *FlagSet) JSON(prefix string, indent string) ([]byte, error) {
-	return json.MarshalIndent(fs, prefix, indent)
+	return json.MarshalIndent(fs.Redact(), prefix, indent)
 }
 
 // endregion: dumps
+// region: redact
+
+func (fs *FlagSet) Redact() interface{} {
+	if fs == nil {
+		return fs
+	}
+	r := *fs
+	r.Entries = redactEntries(fs.Entries)
+	return &r
+}
+
+// endregion: redact
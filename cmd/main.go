@@ -26,6 +26,13 @@ var (
 
 func main() {
 
+	// region: subcommands
+
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(verify(os.Args[2:]))
+	}
+
+	// endregion: subcommands
 	// region: config and cli flags
 
 	Config = *tecfg.NewConfig(os.Args[0])
@@ -36,12 +43,15 @@ func main() {
 		// "float64":  {Desc: "float64 desc", Type: "float64", Def: 77.7},
 
 		"dbUser":        {Desc: "Database user", Type: "string", Def: ""},
-		"dbPasswd":      {Desc: "Database password", Type: "string", Def: ""},
+		"dbPasswd":      {Desc: "Database password", Type: "string", Def: "", Secret: true},
 		"dbPasswd_file": {Desc: "Database password file", Type: "string", Def: ""},
 		"dbName":        {Desc: "Database name", Type: "string", Def: "tex"},
 
-		"loggerLevel": {Desc: "Logger min severity", Type: "int", Def: 5},
-		"logLevel":    {Desc: "Log level everywhere", Type: "int", Def: 6},
+		"loggerLevel":    {Desc: "Logger min severity", Type: "int", Def: 5},
+		"logLevel":       {Desc: "Log level everywhere", Type: "int", Def: 6},
+		"logConfig":      {Desc: "Logger channels as json, overrides loggerLevel (see log/README.md)", Type: "string", Def: ""},
+		"logConfig_file": {Desc: "Logger channels json file", Type: "string", Def: ""},
+		"logOverrides":   {Desc: "Severity per package or file, like db=debug,cfg=warning,main=info", Type: "string", Def: ""},
 	}
 
 	err := fs.ParseCopy()
@@ -75,21 +85,44 @@ func main() {
 	logLevel := syslog.Priority(Config.Entries["logLevel"].Value.(int))
 	loggerLevel := syslog.Priority(Config.Entries["loggerLevel"].Value.(int))
 
-	Logger = *telog.NewLogger()
+	telog.RegisterEncoder("spew", &spewEncoder) // so that logConfig can refer to it
+
+	if logConfig := Config.Entries["logConfig"].Value.(string); logConfig != "" {
+		l, err := telog.NewLoggerFromJSON([]byte(logConfig))
+		if err != nil {
+			panic(err)
+		}
+		Logger = *l
+	} else {
+		Logger = *telog.NewLogger()
+		_, _ = Logger.NewCh("syslog", telog.ChConfig{Type: telog.ChSyslog})
+		_, _ = Logger.NewCh("stdout", telog.ChConfig{Encoder: &telog.EncoderConsole, Severity: &loggerLevel}) // or &spewEncoder for the dump
+	}
 	defer Logger.Close()
-	_, _ = Logger.NewCh(telog.ChConfig{Type: telog.ChSyslog})
-	lfc, _ := Logger.NewCh(telog.ChConfig{Encoder: &spewEncoder, Severity: &loggerLevel})
+
+	if logOverrides := Config.Entries["logOverrides"].Value.(string); logOverrides != "" {
+		overrides, err := telog.ParseOverrides(logOverrides)
+		if err != nil {
+			panic(err)
+		}
+		_ = Logger.SetOverrides(overrides)
+	}
+	_ = tedb.SetDriverLogger(Logger.StdLogger(telog.LOG_ERR)) // the mysql driver's own messages, process wide, set once
 
 	// endregion: logger and channels
 	// region: sample messages
 
-	_ = lfc.Out(logLevel, "entry1", "with", "severity")        // write to identified channel with severity
-	_ = telog.Out(lfc, logLevel, "entry2", "with", "severity") // write to identified channel with severity
-	_ = telog.Out(&Logger, logLevel, "foobar")                 // write to all logger channels with severity
-	_ = Logger.Out(logLevel, *telog.ChDefaults.Mark)           // write to all channels with severity
+	if lfc := Logger.Get("stdout"); lfc != nil {
+		_ = lfc.Out(logLevel, "entry1", "with", "severity")        // write to identified channel with severity
+		_ = telog.Out(lfc, logLevel, "entry2", "with", "severity") // write to identified channel with severity
+	}
+	_ = telog.Out(&Logger, logLevel, "foobar")       // write to all logger channels with severity
+	_ = Logger.Out(logLevel, *telog.ChDefaults.Mark) // write to all channels with severity
+	_ = Logger.Infof("%s is up", "main")             // write to all channels with the severity in the name
+	_ = Logger.HR("db drills")                       // horizontal rule as wide as the terminal, on channels with EncoderConsole
 
 	// _ = lfc.Out(*telog.ChDefaults.Mark)                            // write to identified channel
-	// _ = Logger.Ch[0].Out(*telog.ChDefaults.Mark, "bar", 1, 1.1, true) // write directly to the first channel
+	// _ = Logger.Get("syslog").Out(*telog.ChDefaults.Mark, "bar", 1, 1.1, true) // write directly to a named channel
 	// _ = Logger.Out(*telog.ChDefaults.Mark)                            // write to all channels
 	// _ = telog.Out(nil, LogLevel, "quux")                         // write to nowhere
 
@@ -315,3 +348,23 @@ func main() {
 	// endregion: db
 
 }
+
+// verify checks the hash chain of audit files (ChAudit), like `main verify /var/log/tex.audit`, exits with 1 if any of them is broken
+
+func verify(paths []string) int {
+	if len(paths) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage of %s verify <audit file> [<audit file>...]\n", os.Args[0])
+		return 2
+	}
+	status := 0
+	for _, path := range paths {
+		head, err := telog.Verify(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s (last intact seq: %d)\n", path, err, head.Seq)
+			status = 1
+			continue
+		}
+		fmt.Printf("%s: ok (seq: %d, hash: %s)\n", path, head.Seq, head.Hash)
+	}
+	return status
+}
@@ -12,7 +12,7 @@ import (
 	"time"
 
 	"github.com/SandorMiskey/TEx-kit/log"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -33,6 +33,7 @@ type canExecute interface {
 	appendHistory(s *Statement)
 	Config() *Config
 	exec() interface{}
+	logger() interface{}
 }
 
 type hasHistory interface {
@@ -42,6 +43,8 @@ type hasHistory interface {
 	setHistory(*History)
 }
 
+var _ log.DbConn = (*Db)(nil) // so it can be used as log.ChConfig.Db
+
 // endregion: interface
 // region: struct
 
@@ -53,7 +56,7 @@ type Config struct {
 	DBName string            // Database name
 	DSN    string            //
 	Net    string            // Network type
-	Passwd string            // Password (requires User)
+	Passwd string            `log:"secret"` // Password (requires User)
 	Type   DbType            //
 	User   string            // Username
 	Params map[string]string // Connection parameters
@@ -81,6 +84,7 @@ type Db struct {
 	config  *Config
 	conn    *sql.DB
 	history History
+	out     interface{} // Config.Logger tagged with the connection
 	tx      []*Tx
 }
 
@@ -288,10 +292,21 @@ func (c *Config) SetDefaults() {
 // endregion: defaults
 // region: logger
 
-// TODO: this isn't a good idea, until we implement support for dispatcher function in log/log.go (problem: in the logs we will see this function as a source of entries)
+// every function in this package is a dispatcher as far as log is concerned, entries will show the caller of db.Exec() etc. as their source
+
+func init() {
+	log.HelperPackage()
+}
+
+// SetDriverLogger is where the mysql driver (MySQL and MariaDB) logs things like broken connections on its own, it is process wide,
+// so it's up to the caller, once, eg. with log.Logger.StdLogger(log.LOG_ERR), preferably without a ChDb on a mysql connection among its channels
+
+func SetDriverLogger(l mysql.Logger) error {
+	return mysql.SetLogger(l)
+}
 
 func Logger(db *Db, n ...interface{}) {
-	log.Out(db.config.Logger, *db.config.Loglevel, n...)
+	log.Out(db.logger(), *db.config.Loglevel, n...)
 }
 
 func (db *Db) Logger(n ...interface{}) {
@@ -336,12 +351,14 @@ func Open(cs ...*Config) (*Db, error) {
 	}
 
 	db := Db{config: c}
+	db.out = log.With(c.Logger, "db", c.DBName, "driver", Drivers[c.Type])
 	conn, e := sql.Open(Drivers[c.Type], c.DSN)
 	if e != nil {
 		log.Out(c.Logger, *c.Loglevel, e, c)
 		return nil, e
 	}
 	db.conn = conn
+
 	db.conn.SetMaxOpenConns(*c.MaxOpenConns)
 	db.conn.SetMaxIdleConns(*c.MaxIdleConns)
 	db.conn.SetConnMaxLifetime(*c.MaxLifetime)
@@ -358,7 +375,7 @@ func Open(cs ...*Config) (*Db, error) {
 		return nil, e
 	}
 	// log.Out(c.Logger, *c.Loglevel, fmt.Sprintf("connection is established to %s with %s driver", c.DSN, Drivers[c.Type]))
-	db.Logger(fmt.Sprintf("db.Open(): %s to %s with %s driver", MsgConnEstablished, c.DSN, Drivers[c.Type]))
+	db.Logger("db.Open(): "+MsgConnEstablished, log.F("dsn", c.DSN))
 
 	// endregion: ping
 
@@ -375,12 +392,10 @@ func (c *Config) Open() (*Db, error) {
 func Close(db *Db) error {
 	e := db.Conn().Close()
 	if e != nil {
-		log.Out(db.Config().Logger, *db.Config().Loglevel, e, db)
+		log.Out(db.logger(), *db.Config().Loglevel, e)
 		return e
 	}
-	conf := *db.Config()
-	conf.Logger = nil
-	log.Out(db.Config().Logger, *db.Config().Loglevel, "database connection closed", conf)
+	log.Out(db.logger(), *db.Config().Loglevel, "db.Close(): "+MsgConnClosed)
 	return nil
 }
 
@@ -399,6 +414,10 @@ func (db *Db) Conn() *sql.DB {
 	return db.conn
 }
 
+func (db *Db) Driver() string {
+	return Drivers[db.config.Type]
+}
+
 func (db *Db) exec() interface{} {
 	return db.conn
 }
@@ -407,6 +426,10 @@ func (db *Db) History() History {
 	return db.history
 }
 
+func (db *Db) logger() interface{} {
+	return db.out
+}
+
 func (db *Db) Tx() []*Tx {
 	return db.tx
 }
@@ -447,7 +470,7 @@ func (db *Db) setHistory(h *History) {
 // region: exec
 
 func Exec(i canExecute, s *Statement) error {
-	log.Out(i.Config().Logger, *i.Config().Loglevel, MsgExecStatement, s.SQL, s.Args)
+	log.Out(i.logger(), *i.Config().Loglevel, MsgExecStatement, s.SQL, s.Args)
 
 	// region: xss protection
 
@@ -470,7 +493,7 @@ func Exec(i canExecute, s *Statement) error {
 			}
 		}
 		s.SQL = template.HTMLEscaper(s.SQL)
-		log.Out(i.Config().Logger, *i.Config().Loglevel, MsgExecStatementEscaped, *s)
+		log.Out(i.logger(), *i.Config().Loglevel, MsgExecStatementEscaped, *s)
 	}
 
 	// endregion: injection protection
@@ -486,7 +509,7 @@ func Exec(i canExecute, s *Statement) error {
 	default:
 		s.Err = ErrInvalidExec
 		i.appendHistory(s)
-		log.Out(i.Config().Logger, *i.Config().Loglevel, s.Err)
+		log.Out(i.logger(), *i.Config().Loglevel, s.Err)
 		return s.Err
 	}
 
@@ -498,7 +521,7 @@ func Exec(i canExecute, s *Statement) error {
 	if s.Err != nil {
 		s.Err = fmt.Errorf("%s: %s", ErrExecFailed, s.Err)
 		i.appendHistory(s)
-		log.Out(i.Config().Logger, *i.Config().Loglevel, s.Err)
+		log.Out(i.logger(), *i.Config().Loglevel, s.Err)
 		return s.Err
 	}
 
@@ -510,7 +533,7 @@ func Exec(i canExecute, s *Statement) error {
 		if s.Err != nil {
 			s.Err = fmt.Errorf("%s: %w", ErrExecLastIdFailed, s.Err)
 			i.appendHistory(s)
-			log.Out(i.Config().Logger, *i.Config().Loglevel, s.Err)
+			log.Out(i.logger(), *i.Config().Loglevel, s.Err)
 			return s.Err
 		}
 	}
@@ -519,7 +542,7 @@ func Exec(i canExecute, s *Statement) error {
 	if s.Err != nil {
 		s.Err = fmt.Errorf("%s: %w", ErrExecRowsAffectedFailed, s.Err)
 		i.appendHistory(s)
-		log.Out(i.Config().Logger, *i.Config().Loglevel, s.Err)
+		log.Out(i.logger(), *i.Config().Loglevel, s.Err)
 		return s.Err
 	}
 
@@ -30,7 +30,7 @@ func Begin(db *Db) (*Tx, error) {
 	if e != nil {
 		s.Err = e
 		db.appendHistory(s)
-		log.Out(db.Config().Logger, *db.Config().Loglevel, e)
+		log.Out(db.logger(), *db.Config().Loglevel, e)
 		return nil, e
 	}
 
@@ -54,7 +54,7 @@ func Commit(tx *Tx) error {
 	if e != nil {
 		s.Err = e
 		tx.appendHistory(s)
-		log.Out(tx.Db().Config().Logger, *tx.Db().Config().Loglevel, e)
+		log.Out(tx.logger(), *tx.Db().Config().Loglevel, e)
 		return e
 	}
 
@@ -92,6 +92,10 @@ func (tx *Tx) History() History {
 	return tx.history
 }
 
+func (tx *Tx) logger() interface{} {
+	return tx.Db().logger()
+}
+
 func (tx *Tx) Session() *sql.Tx {
 	return tx.session
 }
@@ -117,7 +121,7 @@ func Rollback(tx *Tx) error {
 	if e != nil {
 		s.Err = e
 		tx.appendHistory(s)
-		log.Out(tx.Db().Config().Logger, *tx.Db().Config().Loglevel, e)
+		log.Out(tx.logger(), *tx.Db().Config().Loglevel, e)
 		return e
 	}
 
@@ -0,0 +1,202 @@
+// region: packages
+
+package log
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// endregion: packages
+// region: types
+
+type QueuePolicy int
+
+type QueueStats struct {
+	Dropped uint64 // entries lost to the overflow policy
+	Failed  uint64 // entries the workers couldn't write
+	Queued  int    // entries waiting right now
+}
+
+type queue struct {
+	dropped uint64 // 64-bit atomics first, for the sake of 32-bit platforms
+	failed  uint64
+	pending int64
+
+	mu     sync.RWMutex
+	closed bool
+
+	ch      *Ch
+	entries chan *Entry
+	policy  QueuePolicy
+	workers sync.WaitGroup
+}
+
+// endregion: types
+// region: constants
+
+const (
+	QueueBlock QueuePolicy = iota
+	QueueDropNewest
+	QueueDropOldest
+)
+
+// endregion: constants
+// region: constructor
+
+func newQueue(c *Ch, size int, policy QueuePolicy, workers int) *queue {
+	q := queue{
+		ch:      c,
+		entries: make(chan *Entry, size),
+		policy:  policy,
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		q.workers.Add(1)
+		go q.work()
+	}
+	return &q
+}
+
+// endregion: constructor
+// region: push and work
+
+// push returns false if the queue is closed, the caller should write the entry itself then
+
+func (q *queue) push(e *Entry) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return false
+	}
+	atomic.AddInt64(&q.pending, 1)
+
+	switch q.policy {
+	case QueueDropNewest:
+		select {
+		case q.entries <- e:
+		default:
+			q.drop()
+		}
+	case QueueDropOldest:
+		for {
+			select {
+			case q.entries <- e:
+				return true
+			default:
+			}
+			select {
+			case <-q.entries:
+				q.drop()
+			default:
+			}
+		}
+	default:
+		q.entries <- e
+	}
+	return true
+}
+
+func (q *queue) drop() {
+	atomic.AddUint64(&q.dropped, 1)
+	atomic.AddInt64(&q.pending, -1)
+}
+
+func (q *queue) work() {
+	defer q.workers.Done()
+	for e := range q.entries {
+		if err := q.ch.emit(e); err != nil {
+			atomic.AddUint64(&q.failed, 1)
+		}
+		atomic.AddInt64(&q.pending, -1)
+	}
+}
+
+// endregion: push and work
+// region: flush and close
+
+func (q *queue) flush(ctx context.Context) error {
+	tick := time.NewTicker(time.Millisecond)
+	defer tick.Stop()
+	for atomic.LoadInt64(&q.pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+	return nil
+}
+
+// close drains the queue and stops the workers, entries after this are written synchronously
+
+func (q *queue) close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.entries)
+	q.mu.Unlock()
+	q.workers.Wait()
+}
+
+func (q *queue) stats() QueueStats {
+	return QueueStats{
+		Dropped: atomic.LoadUint64(&q.dropped),
+		Failed:  atomic.LoadUint64(&q.failed),
+		Queued:  len(q.entries),
+	}
+}
+
+// endregion: flush and close
+// region: ch and logger
+
+// Flush waits until the queue of an async channel is empty, or ctx is done, ChHTTP posts what it has buffered as well
+
+func (c *Ch) Flush(ctx context.Context) error {
+	if c.queue != nil {
+		if e := c.queue.flush(ctx); e != nil {
+			return e
+		}
+	}
+	if c.shipper != nil {
+		return c.shipper.flush(ctx)
+	}
+	if c.audit != nil {
+		return c.audit.sync()
+	}
+	return nil
+}
+
+// Stats adds up the queue and the buffer of ChHTTP
+
+func (c *Ch) Stats() (stats QueueStats) {
+	if c.queue != nil {
+		stats = c.queue.stats()
+	}
+	if c.shipper != nil {
+		s := c.shipper.stats()
+		stats.Dropped += s.Dropped
+		stats.Failed += s.Failed
+		stats.Queued += s.Queued
+	}
+	return stats
+}
+
+func (l *Logger) Flush(ctx context.Context) (e error) {
+	for _, c := range l.Channels() {
+		if err := c.Flush(ctx); err != nil {
+			e = err
+		}
+	}
+	return e
+}
+
+// endregion: ch and logger
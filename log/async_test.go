@@ -0,0 +1,182 @@
+package log_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SandorMiskey/TEx-kit/log"
+)
+
+// stalled is a ChMemory with a queue of 2 and one worker, which is stuck on m0 until release is called
+
+func stalled(t *testing.T, policy log.QueuePolicy) (ch *log.Ch, release func()) {
+	t.Helper()
+	size := 2
+	workers := 1
+	ch = newMemory(t, log.ChConfig{Queue: &size, QueuePolicy: &policy, Workers: &workers})
+	ch.Flush(context.Background())
+	ch.Reset()
+
+	started := make(chan struct{}, 1)
+	gate := make(chan struct{})
+	ch.AddHook(log.Hook{Post: func(e *log.Entry, err error) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-gate
+	}})
+	ch.Out("m0")
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the worker hasn't picked up m0")
+	}
+	return ch, func() { close(gate) }
+}
+
+func messages(ch *log.Ch) string {
+	msgs := make([]string, 0)
+	for _, e := range ch.Entries(nil) {
+		msgs = append(msgs, e.Message())
+	}
+	return strings.Join(msgs, ",")
+}
+
+func TestQueuePolicies(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		policy  log.QueuePolicy
+		written string
+		dropped uint64
+	}{
+		{"drop newest", log.QueueDropNewest, "m0,m1,m2", 2},
+		{"drop oldest", log.QueueDropOldest, "m0,m3,m4", 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ch, release := stalled(t, tc.policy)
+			for _, m := range []string{"m1", "m2", "m3", "m4"} {
+				ch.Out(m)
+			}
+			if stats := ch.Stats(); stats.Dropped != tc.dropped || stats.Queued != 2 {
+				t.Errorf("stats %+v while stalled", stats)
+			}
+			release()
+			if e := ch.Flush(context.Background()); e != nil {
+				t.Fatal(e)
+			}
+			if got := messages(ch); got != tc.written {
+				t.Errorf("written %s, want %s", got, tc.written)
+			}
+			if stats := ch.Stats(); stats.Dropped != tc.dropped || stats.Queued != 0 || stats.Failed != 0 {
+				t.Errorf("stats %+v", stats)
+			}
+		})
+	}
+}
+
+func TestQueueBlock(t *testing.T) {
+	ch, release := stalled(t, log.QueueBlock)
+	ch.Out("m1")
+	ch.Out("m2")
+
+	done := make(chan struct{})
+	go func() {
+		ch.Out("m3")
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Out() returned with a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Out() is still blocked")
+	}
+	if e := ch.Flush(context.Background()); e != nil {
+		t.Fatal(e)
+	}
+	if got := messages(ch); got != "m0,m1,m2,m3" {
+		t.Errorf("written %s", got)
+	}
+	if stats := ch.Stats(); stats.Dropped != 0 || stats.Queued != 0 {
+		t.Errorf("stats %+v", stats)
+	}
+}
+
+func TestQueueFlushDeadline(t *testing.T) {
+	ch, release := stalled(t, log.QueueBlock)
+	defer release()
+	ch.Out("m1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if e := ch.Flush(ctx); e != context.DeadlineExceeded {
+		t.Fatalf("flush: %v, want %v", e, context.DeadlineExceeded)
+	}
+	if stats := ch.Stats(); stats.Queued != 1 {
+		t.Errorf("stats %+v", stats)
+	}
+}
+
+func TestQueueDrainOnClose(t *testing.T) {
+	ch, release := stalled(t, log.QueueBlock)
+	ch.Out("m1")
+	ch.Out("m2")
+
+	closed := make(chan error)
+	go func() { closed <- ch.Close() }()
+	select {
+	case <-closed:
+		t.Fatal("Close() returned before the queue was drained")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	if e := <-closed; e != nil {
+		t.Fatal(e)
+	}
+
+	// the bye is written synchronously, after what was queued
+	got := messages(ch)
+	if !strings.HasPrefix(got, "m0,m1,m2,") || !strings.HasSuffix(got, "leaving...") {
+		t.Errorf("written %s", got)
+	}
+	if stats := ch.Stats(); stats.Queued != 0 || stats.Dropped != 0 {
+		t.Errorf("stats %+v", stats)
+	}
+}
+
+func TestQueueFailed(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "ro.log")
+	os.WriteFile(name, nil, 0o644)
+	f, e := os.Open(name) // read only, every write fails
+	if e != nil {
+		t.Fatal(e)
+	}
+	size := 4
+	ch, e := log.NewCh(log.ChConfig{Type: log.ChFile, File: f, Queue: &size})
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer ch.Close()
+	ch.Flush(context.Background())
+	before := ch.Stats().Failed
+
+	for i := 0; i < 3; i++ {
+		ch.Out(log.LOG_ERR, "nowhere to go")
+	}
+	if e := ch.Flush(context.Background()); e != nil {
+		t.Fatal(e)
+	}
+	if failed := ch.Stats().Failed - before; failed != 3 {
+		t.Errorf("%d failed, want 3", failed)
+	}
+}
@@ -0,0 +1,284 @@
+// region: packages
+
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// endregion: packages
+// region: types
+
+// AuditHead is the last link of the chain, keep it somewhere else as well, since cutting off the end of the file can't be told otherwise
+
+type AuditHead struct {
+	Seq  uint64
+	Hash string
+}
+
+// audit appends the lines of ChAudit to its file, each encoded entry gets "seq", "prev" and "hash" as its last keys,
+// where hash is the sha256 of the line up to and including prev, so each line vouches for the one before
+
+type audit struct {
+	mu    sync.Mutex
+	dirty bool
+	file  *os.File
+	fsync time.Duration
+	head  AuditHead
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// endregion: types
+// region: constructor
+
+// the prev of the first entry
+var auditGenesis = strings.Repeat("0", sha256.Size*2)
+
+// auditHashKey is what the hash of the line is cut off at
+const auditHashKey = `,"hash":"`
+
+// the keys of the links, EncoderJSON moves fields by these names aside, other encoders mustn't produce them
+var auditKeys = []string{"seq", "prev", "hash"}
+
+var auditLinks = regexp.MustCompile(`"seq":(\d+),"prev":"([0-9a-f]{64})"}$`)
+
+func newAudit(c *Ch) (*audit, error) {
+	head, e := auditTail(c.Config.File.(string))
+	if e != nil {
+		return nil, e
+	}
+	a := audit{
+		file:  c.File,
+		fsync: *c.Config.Fsync,
+		head:  head,
+		done:  make(chan struct{}),
+	}
+	if a.fsync > 0 {
+		a.wg.Add(1)
+		go a.run()
+	}
+	return &a, nil
+}
+
+// auditTail picks up the chain where the last run left it, the last line has to be complete and intact
+
+func auditTail(path string) (AuditHead, error) {
+	head := AuditHead{Hash: auditGenesis}
+	f, e := os.Open(path)
+	if e != nil {
+		return head, e
+	}
+	defer f.Close()
+	info, e := f.Stat()
+	if e != nil || info.Size() == 0 {
+		return head, e
+	}
+
+	// read backwards until there is a whole line
+	size := info.Size()
+	chunk := int64(64 << 10)
+	for {
+		if chunk > size {
+			chunk = size
+		}
+		b := make([]byte, chunk)
+		if _, e := f.ReadAt(b, size-chunk); e != nil && e != io.EOF {
+			return head, e
+		}
+		if b[len(b)-1] != '\n' {
+			return head, fmt.Errorf("%s: %s: %s", ErrAuditBroken, path, "last line is incomplete")
+		}
+		b = b[:len(b)-1]
+		i := bytes.LastIndexByte(b, '\n')
+		if i < 0 && chunk < size {
+			chunk *= 2
+			continue
+		}
+		seq, _, hash, e := auditParse(b[i+1:])
+		if e != nil {
+			return head, fmt.Errorf("%s: %s: last line: %s", ErrAuditBroken, path, e)
+		}
+		return AuditHead{Seq: seq, Hash: hash}, nil
+	}
+}
+
+// endregion: constructor
+// region: write
+
+func (a *audit) write(o string) error {
+	body := strings.TrimSpace(o)
+	if !strings.HasPrefix(body, "{") || !strings.HasSuffix(body, "}") || strings.ContainsAny(body, "\r\n") {
+		return fmt.Errorf("%s: %s", ErrInvalidAudit, "the encoder has to produce single line json objects")
+	}
+	if key := auditCollision(body); key != "" {
+		return fmt.Errorf("%s: %s %q", ErrInvalidAudit, "the entry has its own", key)
+	}
+	sep := ","
+	if body == "{}" {
+		sep = ""
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	seq := a.head.Seq + 1
+	body = fmt.Sprintf(`%s%s"seq":%d,"prev":"%s"}`, body[:len(body)-1], sep, seq, a.head.Hash)
+	sum := sha256.Sum256([]byte(body))
+	hash := hex.EncodeToString(sum[:])
+	if _, e := a.file.WriteString(body[:len(body)-1] + auditHashKey + hash + "\"}\n"); e != nil {
+		return e
+	}
+	a.head = AuditHead{Seq: seq, Hash: hash}
+
+	if a.fsync > 0 {
+		a.dirty = true
+		return nil
+	}
+	return a.file.Sync()
+}
+
+// auditCollision returns the first top level key of the object that would be duplicated by the links, or "" if there is none
+
+func auditCollision(body string) string {
+	d := json.NewDecoder(strings.NewReader(body))
+	if t, e := d.Token(); e != nil || t != json.Delim('{') {
+		return ""
+	}
+	for d.More() {
+		t, e := d.Token()
+		if e != nil {
+			return ""
+		}
+		for _, k := range auditKeys {
+			if t == k {
+				return k
+			}
+		}
+		var value json.RawMessage
+		if e := d.Decode(&value); e != nil {
+			return ""
+		}
+	}
+	return ""
+}
+
+// run fsyncs what was written since the last tick, if ChConfig.Fsync batches them
+
+func (a *audit) run() {
+	defer a.wg.Done()
+	tick := time.NewTicker(a.fsync)
+	defer tick.Stop()
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-tick.C:
+			a.sync()
+		}
+	}
+}
+
+func (a *audit) sync() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.dirty {
+		return nil
+	}
+	a.dirty = false
+	return a.file.Sync()
+}
+
+func (a *audit) close() error {
+	if a.fsync > 0 {
+		close(a.done)
+		a.wg.Wait()
+	}
+	if e := a.sync(); e != nil {
+		a.file.Close()
+		return e
+	}
+	return a.file.Close()
+}
+
+// Head returns the last link of a ChAudit channel
+
+func (c *Ch) Head() AuditHead {
+	if c.audit == nil {
+		return AuditHead{}
+	}
+	c.audit.mu.Lock()
+	defer c.audit.mu.Unlock()
+	return c.audit.head
+}
+
+// endregion: write
+// region: verify
+
+// auditParse checks the hash of a line and returns its links
+
+func auditParse(line []byte) (seq uint64, prev string, hash string, e error) {
+	i := bytes.LastIndex(line, []byte(auditHashKey))
+	if i < 0 || !bytes.HasSuffix(line, []byte(`"}`)) {
+		return 0, "", "", fmt.Errorf("%s", "no hash")
+	}
+	hash = string(line[i+len(auditHashKey) : len(line)-2])
+	body := append(append([]byte{}, line[:i]...), '}')
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != hash {
+		return 0, "", "", fmt.Errorf("%s", "hash mismatch, the entry has been edited")
+	}
+
+	// the links are always the last keys, whatever the fields of the entry are called
+	m := auditLinks.FindSubmatch(body)
+	if m == nil {
+		return 0, "", "", fmt.Errorf("%s", "no seq and prev")
+	}
+	seq, e = strconv.ParseUint(string(m[1]), 10, 64)
+	return seq, string(m[2]), hash, e
+}
+
+// Verify walks the chain of an audit file, the error tells the first line where it's broken (edited, missing or reordered entries),
+// the head is the last intact link, compare it with one kept elsewhere to tell if the end has been cut off
+
+func Verify(path string) (AuditHead, error) {
+	head := AuditHead{Hash: auditGenesis}
+	f, e := os.Open(path)
+	if e != nil {
+		return head, e
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 64<<20)
+	for n := 1; scanner.Scan(); n++ {
+		seq, prev, hash, e := auditParse(scanner.Bytes())
+		switch {
+		case e != nil:
+			return head, fmt.Errorf("%s: line %d: %s", ErrAuditBroken, n, e)
+		case seq <= head.Seq:
+			return head, fmt.Errorf("%s: line %d: seq %d after %d, reordered or duplicated", ErrAuditBroken, n, seq, head.Seq)
+		case seq > head.Seq+1:
+			return head, fmt.Errorf("%s: line %d: seq %d after %d, %d missing", ErrAuditBroken, n, seq, head.Seq, seq-head.Seq-1)
+		case prev != head.Hash:
+			return head, fmt.Errorf("%s: line %d: prev is not the hash of seq %d", ErrAuditBroken, n, head.Seq)
+		}
+		head = AuditHead{Seq: seq, Hash: hash}
+	}
+	return head, scanner.Err()
+}
+
+// endregion: verify
@@ -0,0 +1,177 @@
+package log_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SandorMiskey/TEx-kit/log"
+)
+
+// newAudit writes n entries and returns the lines of the file, the welcome is the first one
+
+func newAudit(t *testing.T, path string, n int) (log.AuditHead, [][]byte) {
+	t.Helper()
+	ch, e := log.NewCh(log.ChConfig{Type: log.ChAudit, File: path})
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i := 0; i < n; i++ {
+		if e := ch.Out(log.LOG_NOTICE, fmt.Sprintf("entry %d", i), log.F("seq", i)); e != nil {
+			t.Fatal(e)
+		}
+	}
+	if e := ch.Close(); e != nil {
+		t.Fatal(e)
+	}
+	b, e := os.ReadFile(path)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return ch.Head(), bytes.SplitAfter(b, []byte("\n"))[:bytes.Count(b, []byte("\n"))]
+}
+
+func TestAuditVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	head, lines := newAudit(t, path, 5)
+	if head.Seq != uint64(len(lines)) {
+		t.Fatalf("head %d, %d lines", head.Seq, len(lines))
+	}
+	verified, e := log.Verify(path)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if verified != head {
+		t.Errorf("verified %+v, head %+v", verified, head)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		tamper func(lines [][]byte) [][]byte
+		err    string
+		seq    uint64
+	}{
+		{
+			"edited",
+			func(lines [][]byte) [][]byte {
+				lines[2] = bytes.Replace(lines[2], []byte("entry 1"), []byte("entry X"), 1)
+				return lines
+			},
+			"line 3: hash mismatch", 2,
+		},
+		{
+			"deleted",
+			func(lines [][]byte) [][]byte {
+				return append(lines[:2:2], lines[3:]...)
+			},
+			"line 3: seq 4 after 2, 1 missing", 2,
+		},
+		{
+			"reordered",
+			func(lines [][]byte) [][]byte {
+				lines[2], lines[3] = lines[3], lines[2]
+				return lines
+			},
+			"line 3: seq 4 after 2", 2,
+		},
+		{
+			"duplicated",
+			func(lines [][]byte) [][]byte {
+				return append(lines[:3:3], lines[2:]...)
+			},
+			"line 4: seq 3 after 3, reordered or duplicated", 3,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cp := make([][]byte, len(lines))
+			for i := range lines {
+				cp[i] = append([]byte{}, lines[i]...)
+			}
+			tampered := filepath.Join(t.TempDir(), "audit.log")
+			os.WriteFile(tampered, bytes.Join(tc.tamper(cp), nil), 0o644)
+
+			head, e := log.Verify(tampered)
+			if e == nil || !strings.HasPrefix(e.Error(), log.ErrAuditBroken.Error()) || !strings.Contains(e.Error(), tc.err) {
+				t.Errorf("error: %v, want %q", e, tc.err)
+			}
+			if head.Seq != tc.seq {
+				t.Errorf("head at seq %d, want %d", head.Seq, tc.seq)
+			}
+		})
+	}
+}
+
+func TestAuditResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	first, _ := newAudit(t, path, 3)
+
+	ch, e := log.NewCh(log.ChConfig{Type: log.ChAudit, File: path})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if head := ch.Head(); head.Seq != first.Seq+1 { // the welcome
+		t.Errorf("reopened at seq %d, after %d", head.Seq, first.Seq)
+	}
+	ch.Out(log.LOG_NOTICE, "after reopen")
+	ch.Close()
+	last := ch.Head()
+
+	verified, e := log.Verify(path)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if verified != last {
+		t.Errorf("verified %+v, head %+v", verified, last)
+	}
+
+	// a torn last line stops the next run instead of forking the chain
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"msg":"torn`)
+	f.Close()
+	if _, e := log.NewCh(log.ChConfig{Type: log.ChAudit, File: path}); e == nil || !strings.Contains(e.Error(), "incomplete") {
+		t.Errorf("reopened a torn file: %v", e)
+	}
+}
+
+func TestAuditReservedKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	ch, e := log.NewCh(log.ChConfig{Type: log.ChAudit, File: path})
+	if e != nil {
+		t.Fatal(e)
+	}
+	ch.Out(log.LOG_NOTICE, "x", log.F("seq", 99), log.F("prev", "p"), log.F("hash", "h"))
+	ch.Close()
+
+	b, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	line := lines[1] // after the welcome
+	for _, k := range []string{"seq", "prev", "hash"} {
+		if n := strings.Count(line, `"`+k+`":`); n != 1 {
+			t.Errorf("%d %q keys in %s", n, k, line)
+		}
+	}
+	if !strings.Contains(line, `"_seq":99,"_prev":"p","_hash":"h"`) {
+		t.Errorf("fields are not moved aside: %s", line)
+	}
+	if _, e := log.Verify(path); e != nil {
+		t.Error(e)
+	}
+
+	// other encoders are on their own
+	var encoder log.Encoder = func(c *log.Ch, n ...interface{}) (string, error) {
+		return `{"msg":"x","nested":{"seq":1},"seq":2}`, nil
+	}
+	path = filepath.Join(t.TempDir(), "custom.log")
+	welcome := ""
+	ch, e = log.NewCh(log.ChConfig{Type: log.ChAudit, File: path, Encoder: &encoder, Welcome: &welcome})
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer ch.Close()
+	if e := ch.Out(log.LOG_NOTICE, "x"); e == nil || !strings.Contains(e.Error(), `own "seq"`) {
+		t.Errorf("error: %v", e)
+	}
+}
@@ -0,0 +1,448 @@
+// region: packages
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// endregion: packages
+// region: types
+
+// chConfigJSON is what ChConfig looks like in a config file: names instead of constants, strings instead of pointers and functions.
+// Db, Hooks, Slog and TLS can't come from a file, set them on the result if needed.
+
+type chConfigJSON struct {
+	Addr           *string             `json:"addr"`
+	Backoff        *jsonDuration       `json:"backoff"`
+	BackoffMax     *jsonDuration       `json:"backoffMax"`
+	BatchSize      *int                `json:"batchSize"`
+	BatchWait      *jsonDuration       `json:"batchWait"`
+	BufferSize     *int                `json:"bufferSize"`
+	Bye            *string             `json:"bye"`
+	DbTable        *string             `json:"dbTable"`
+	Dedup          *jsonDuration       `json:"dedup"`
+	Delimiter      *string             `json:"delimiter"`
+	Depth          *int                `json:"depth"`
+	Encoder        *string             `json:"encoder"`
+	Facility       *string             `json:"facility"`
+	File           *string             `json:"file"`
+	FilePerm       *string             `json:"filePerm"`
+	Flags          *int                `json:"flags"`
+	Framing        *string             `json:"framing"`
+	Fsync          *jsonDuration       `json:"fsync"`
+	Gzip           *bool               `json:"gzip"`
+	Headers        map[string]string   `json:"headers"`
+	Hostname       *string             `json:"hostname"`
+	Index          *string             `json:"index"`
+	Mark           *string             `json:"mark"`
+	MemorySize     *int                `json:"memorySize"`
+	Name           *string             `json:"name"`
+	Network        *string             `json:"network"`
+	Overrides      *string             `json:"overrides"`
+	Prefix         *string             `json:"prefix"`
+	PushFormat     *string             `json:"pushFormat"`
+	Queue          *int                `json:"queue"`
+	QueuePolicy    *string             `json:"queuePolicy"`
+	RateLimits     map[string]rateJSON `json:"rateLimits"`
+	Redact         *bool               `json:"redact"`
+	Retries        *int                `json:"retries"`
+	RotateCompress *bool               `json:"rotateCompress"`
+	RotateEvery    *string             `json:"rotateEvery"`
+	RotateKeep     *int                `json:"rotateKeep"`
+	RotateMaxAge   *jsonDuration       `json:"rotateMaxAge"`
+	RotateSize     *int64              `json:"rotateSize"`
+	ScopeSize      *int                `json:"scopeSize"`
+	ScopeTrigger   *jsonSeverity       `json:"scopeTrigger"`
+	Severity       *jsonSeverity       `json:"severity"`
+	SeverityLabels map[string]string   `json:"severityLabels"`
+	Spool          *string             `json:"spool"`
+	SpoolSize      *int64              `json:"spoolSize"`
+	StackTrace     *string             `json:"stackTrace"`
+	SyslogFormat   *string             `json:"syslogFormat"`
+	Tag            *string             `json:"tag"`
+	Timeout        *jsonDuration       `json:"timeout"`
+	Type           *string             `json:"type"`
+	URL            *string             `json:"url"`
+	Welcome        *string             `json:"welcome"`
+	Width          *int                `json:"width"`
+	Workers        *int                `json:"workers"`
+	Wrap           *bool               `json:"wrap"`
+}
+
+type rateJSON struct {
+	N   int          `json:"n"`
+	Per jsonDuration `json:"per"`
+}
+
+// "1m30s" or nanoseconds
+
+type jsonDuration time.Duration
+
+// "warning" or 4
+
+type jsonSeverity syslog.Priority
+
+// endregion: types
+// region: names
+
+var encoders = struct {
+	sync.RWMutex
+	names map[string]*Encoder
+}{names: map[string]*Encoder{
+	"console": &EncoderConsole,
+	"flat":    &EncoderFlat,
+	"json":    &EncoderJSON,
+}}
+
+var facilityNames = map[string]syslog.Priority{
+	"kern":     LOG_KERN,
+	"user":     LOG_USER,
+	"mail":     LOG_MAIL,
+	"daemon":   LOG_DAEMON,
+	"auth":     LOG_AUTH,
+	"syslog":   LOG_SYSLOG,
+	"lpr":      LOG_LPR,
+	"news":     LOG_NEWS,
+	"uucp":     LOG_UUCP,
+	"cron":     LOG_CRON,
+	"authpriv": LOG_AUTHPRIV,
+	"ftp":      LOG_FTP,
+	"local0":   LOG_LOCAL0,
+	"local1":   LOG_LOCAL1,
+	"local2":   LOG_LOCAL2,
+	"local3":   LOG_LOCAL3,
+	"local4":   LOG_LOCAL4,
+	"local5":   LOG_LOCAL5,
+	"local6":   LOG_LOCAL6,
+	"local7":   LOG_LOCAL7,
+}
+
+var framingNames = map[string]Framing{
+	"newline": FrameNewline,
+	"length":  FrameLength,
+}
+
+var pushFormatNames = map[string]PushFormat{
+	"elastic": PushElastic,
+	"loki":    PushLoki,
+}
+
+var queuePolicyNames = map[string]QueuePolicy{
+	"block":       QueueBlock,
+	"drop-newest": QueueDropNewest,
+	"drop-oldest": QueueDropOldest,
+}
+
+var rotationNames = map[string]Rotation{
+	"never":  RotateNever,
+	"hourly": RotateHourly,
+	"daily":  RotateDaily,
+}
+
+var syslogFormatNames = map[string]SyslogFormat{
+	"rfc5424": RFC5424,
+	"rfc3164": RFC3164,
+}
+
+// RegisterEncoder makes e available by name in config files, "console", "flat" and "json" are there by default
+
+func RegisterEncoder(name string, e *Encoder) {
+	encoders.Lock()
+	defer encoders.Unlock()
+	encoders.names[name] = e
+}
+
+func EncoderByName(name string) (*Encoder, bool) {
+	encoders.RLock()
+	defer encoders.RUnlock()
+	e, ok := encoders.names[name]
+	return e, ok
+}
+
+func ParseChType(s string) (ChType, error) {
+	for t, name := range chTypeNames {
+		if name == strings.ToLower(s) && t != ChUndefined {
+			return t, nil
+		}
+	}
+	return ChUndefined, fmt.Errorf("%s: %s", ErrInvalidLoggerOrChannel, s)
+}
+
+// lookup is for the small name -> constant maps above, the error lists what would have been accepted
+
+func lookup[T any](names map[string]T, field string, s string) (T, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	valid := make([]string, 0, len(names))
+	for name := range names {
+		valid = append(valid, name)
+	}
+	sort.Strings(valid)
+	var zero T
+	return zero, fmt.Errorf("%s: %s=%q (%s)", ErrInvalidConfig, field, s, strings.Join(valid, ", "))
+}
+
+// endregion: names
+// region: unmarshal
+
+func (d *jsonDuration) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		v, e := time.ParseDuration(s)
+		*d = jsonDuration(v)
+		return e
+	}
+	var n int64
+	if e := json.Unmarshal(b, &n); e != nil {
+		return fmt.Errorf("%s: duration %s", ErrInvalidConfig, b)
+	}
+	*d = jsonDuration(n)
+	return nil
+}
+
+func (p *jsonSeverity) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	v, e := ParseSeverity(s)
+	*p = jsonSeverity(v)
+	return e
+}
+
+func (d *jsonDuration) ptr() *time.Duration {
+	if d == nil {
+		return nil
+	}
+	v := time.Duration(*d)
+	return &v
+}
+
+// UnmarshalJSON fills the config from its file form, see chConfigJSON, unset keys are left nil for NewCh() to default
+
+func (c *ChConfig) UnmarshalJSON(b []byte) (e error) {
+	var j chConfigJSON
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.DisallowUnknownFields()
+	if e := dec.Decode(&j); e != nil {
+		return fmt.Errorf("%s: %s", ErrInvalidConfig, e)
+	}
+
+	*c = ChConfig{
+		Addr:           j.Addr,
+		Backoff:        j.Backoff.ptr(),
+		BackoffMax:     j.BackoffMax.ptr(),
+		BatchSize:      j.BatchSize,
+		BatchWait:      j.BatchWait.ptr(),
+		BufferSize:     j.BufferSize,
+		Bye:            j.Bye,
+		DbTable:        j.DbTable,
+		Dedup:          j.Dedup.ptr(),
+		Delimiter:      j.Delimiter,
+		Depth:          j.Depth,
+		Flags:          j.Flags,
+		Fsync:          j.Fsync.ptr(),
+		Gzip:           j.Gzip,
+		Hostname:       j.Hostname,
+		Index:          j.Index,
+		Mark:           j.Mark,
+		MemorySize:     j.MemorySize,
+		Name:           j.Name,
+		Network:        j.Network,
+		Prefix:         j.Prefix,
+		Queue:          j.Queue,
+		Redact:         j.Redact,
+		Retries:        j.Retries,
+		RotateCompress: j.RotateCompress,
+		RotateKeep:     j.RotateKeep,
+		RotateMaxAge:   j.RotateMaxAge.ptr(),
+		RotateSize:     j.RotateSize,
+		ScopeSize:      j.ScopeSize,
+		Spool:          j.Spool,
+		SpoolSize:      j.SpoolSize,
+		Tag:            j.Tag,
+		Timeout:        j.Timeout.ptr(),
+		URL:            j.URL,
+		Welcome:        j.Welcome,
+		Width:          j.Width,
+		Workers:        j.Workers,
+		Wrap:           j.Wrap,
+	}
+
+	if j.Type != nil {
+		if c.Type, e = ParseChType(*j.Type); e != nil {
+			return e
+		}
+	}
+	if j.Encoder != nil {
+		enc, ok := EncoderByName(*j.Encoder)
+		if !ok {
+			return fmt.Errorf("%s: encoder=%q", ErrInvalidConfig, *j.Encoder)
+		}
+		c.Encoder = enc
+	}
+	if j.File != nil {
+		switch *j.File {
+		case "stdout":
+			c.File = os.Stdout
+		case "stderr":
+			c.File = os.Stderr
+		default:
+			c.File = *j.File
+		}
+	}
+	if j.FilePerm != nil {
+		perm, e := strconv.ParseUint(*j.FilePerm, 8, 32)
+		if e != nil {
+			return fmt.Errorf("%s: filePerm=%q", ErrInvalidConfig, *j.FilePerm)
+		}
+		v := int(perm)
+		c.FilePerm = &v
+	}
+	if j.Headers != nil {
+		c.Headers = http.Header{}
+		for k, v := range j.Headers {
+			c.Headers.Set(k, v)
+		}
+	}
+	if j.Severity != nil {
+		v := syslog.Priority(*j.Severity)
+		c.Severity = &v
+	}
+	if j.ScopeTrigger != nil {
+		v := syslog.Priority(*j.ScopeTrigger)
+		c.ScopeTrigger = &v
+	}
+	if j.SeverityLabels != nil {
+		labels := SeverityLabels{}
+		for k, v := range j.SeverityLabels {
+			p, e := ParseSeverity(k)
+			if e != nil {
+				return e
+			}
+			labels[p] = v
+		}
+		c.SeverityLabels = &labels
+	}
+	if j.RateLimits != nil {
+		limits := RateLimits{}
+		for k, v := range j.RateLimits {
+			p, e := ParseSeverity(k)
+			if e != nil {
+				return e
+			}
+			limits[p] = Rate{N: v.N, Per: time.Duration(v.Per)}
+		}
+		c.RateLimits = &limits
+	}
+	if j.Overrides != nil {
+		o, e := ParseOverrides(*j.Overrides)
+		if e != nil {
+			return e
+		}
+		c.Overrides = &o
+	}
+	if j.StackTrace != nil {
+		v := StackOff
+		if *j.StackTrace != "off" {
+			if v, e = ParseSeverity(*j.StackTrace); e != nil {
+				return e
+			}
+		}
+		c.StackTrace = &v
+	}
+
+	// the rest are plain name -> constant lookups
+	if j.Facility != nil {
+		v, e := lookup(facilityNames, "facility", *j.Facility)
+		if e != nil {
+			return e
+		}
+		c.Facility = &v
+	}
+	if j.Framing != nil {
+		v, e := lookup(framingNames, "framing", *j.Framing)
+		if e != nil {
+			return e
+		}
+		c.Framing = &v
+	}
+	if j.PushFormat != nil {
+		v, e := lookup(pushFormatNames, "pushFormat", *j.PushFormat)
+		if e != nil {
+			return e
+		}
+		c.PushFormat = &v
+	}
+	if j.QueuePolicy != nil {
+		v, e := lookup(queuePolicyNames, "queuePolicy", *j.QueuePolicy)
+		if e != nil {
+			return e
+		}
+		c.QueuePolicy = &v
+	}
+	if j.RotateEvery != nil {
+		v, e := lookup(rotationNames, "rotateEvery", *j.RotateEvery)
+		if e != nil {
+			return e
+		}
+		c.RotateEvery = &v
+	}
+	if j.SyslogFormat != nil {
+		v, e := lookup(syslogFormatNames, "syslogFormat", *j.SyslogFormat)
+		if e != nil {
+			return e
+		}
+		c.SyslogFormat = &v
+	}
+	return nil
+}
+
+// endregion: unmarshal
+// region: constructor
+
+// NewLoggerFromConfig creates the channels in order, if any of them fails the ones already created are closed
+//
+//	{"channels": [
+//		{"name": "stdout", "file": "stdout", "severity": "info"},
+//		{"name": "json", "file": "/var/log/tex.json", "encoder": "json", "rotateEvery": "daily", "rotateKeep": 7},
+//		{"name": "remote", "type": "syslog-remote", "addr": "logs:6514", "network": "tls", "severity": "warning"}
+//	]}
+
+func NewLoggerFromConfig(c LoggerConfig) (*Logger, error) {
+	l := NewLogger()
+	for i, cc := range c.Ch {
+		name := cc.Type.String()
+		if cc.Type == ChUndefined {
+			name = ChDefaults.Type.String()
+		}
+		if cc.Name != nil {
+			name = *cc.Name
+		}
+		if _, e := l.NewCh(name, cc); e != nil {
+			l.Close()
+			return nil, fmt.Errorf("%s: channel #%d (%s): %s", ErrInvalidConfig, i, name, e)
+		}
+	}
+	return l, nil
+}
+
+// NewLoggerFromJSON is NewLoggerFromConfig for the json form, eg. the content of a file or a cfg entry
+
+func NewLoggerFromJSON(b []byte) (*Logger, error) {
+	var c LoggerConfig
+	if e := json.Unmarshal(b, &c); e != nil {
+		return nil, e
+	}
+	return NewLoggerFromConfig(c)
+}
+
+// endregion: constructor
@@ -0,0 +1,390 @@
+// region: packages
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/syslog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// endregion: packages
+// region: types
+
+// console is what EncoderConsole finds out about the output of the channel, entry by entry, since terminals get resized
+
+type console struct {
+	color bool
+	tty   bool
+	width int // 0 means unlimited
+}
+
+// endregion: types
+// region: constants
+
+const (
+	consoleCallerWidth = 18
+	consoleDepth       = 8 // of pretty printed values
+	consoleMinWidth    = 20
+	consoleTime        = "15:04:05.000"
+)
+
+var consoleLevels = map[syslog.Priority]string{
+	LOG_EMERG:   "EMERG",
+	LOG_ALERT:   "ALERT",
+	LOG_CRIT:    "CRIT",
+	LOG_ERR:     "ERR",
+	LOG_WARNING: "WARN",
+	LOG_NOTICE:  "NOTE",
+	LOG_INFO:    "INFO",
+	LOG_DEBUG:   "DEBUG",
+}
+
+// ansi sgr parameters
+var consoleColors = map[syslog.Priority]string{
+	LOG_EMERG:   "1;97;41",
+	LOG_ALERT:   "1;97;41",
+	LOG_CRIT:    "1;31",
+	LOG_ERR:     "31",
+	LOG_WARNING: "33",
+	LOG_NOTICE:  "36",
+	LOG_INFO:    "32",
+	LOG_DEBUG:   "90",
+}
+
+const (
+	consoleDim   = "2"
+	consoleKey   = "36"
+	consoleReset = "\x1b[0m"
+)
+
+var consoleKeys = regexp.MustCompile(`(^|\s)([^\s=]+)=`)
+
+// endregion: constants
+// region: encoder
+
+// EncoderConsole is for humans: aligned time, level and caller columns, colors and the width of the terminal if the channel writes to one,
+// plain text otherwise (or if $NO_COLOR is set). Long lines are wrapped (or truncated, see ChConfig.Wrap), structs, maps and slices
+// are pretty printed below the line.
+
+var EncoderConsole Encoder = func(c *Ch, n ...interface{}) (s string, e error) {
+
+	// entry is only present when called from Out()
+	entry := c.Entry()
+	if entry == nil {
+		entry = &Entry{Time: time.Now()}
+	}
+	if len(n) > 0 && entry.Severity != nil && n[0] == *entry.Severity {
+		n = n[1:]
+	}
+	args, fields := splitFields(n)
+	fields = joinFields(c.Fields(), fields)
+	con := consoleOf(c)
+
+	// columns
+	level := ""
+	if entry.Severity != nil {
+		level = consoleLevels[*entry.Severity]
+	}
+	caller := ""
+	if entry.Caller.File != "" {
+		caller = filepath.Base(entry.Caller.File) + ":" + strconv.Itoa(entry.Caller.Line)
+	}
+	head := []string{
+		entry.Time.Format(consoleTime),
+		consolePad(level, 5),
+		consolePad(consoleLeft(caller, consoleCallerWidth), consoleCallerWidth),
+	}
+	indent := strings.Repeat(" ", utf8.RuneCountInString(strings.Join(head, " "))+1)
+
+	// message, whatever doesn't fit into one line goes below
+	words := make([]string, 0, len(args)+len(fields))
+	blocks := make([]string, 0)
+	for _, v := range args {
+		if block, ok := consoleBlock(v, indent); ok {
+			blocks = append(blocks, block)
+			continue
+		}
+		words = append(words, fmt.Sprintf("%+v", v))
+	}
+	for _, f := range fields {
+		words = append(words, f.String())
+	}
+	lines := []string{strings.Join(words, " ")}
+	if width := con.width - len(indent); con.width > 0 && width >= consoleMinWidth {
+		if *c.Config.Wrap {
+			lines = consoleWrap(lines[0], width)
+		} else {
+			lines[0] = consoleTruncate(lines[0], width)
+		}
+	}
+
+	// colors
+	if con.color {
+		head[0] = consolePaint(head[0], consoleDim)
+		if entry.Severity != nil {
+			head[1] = consolePaint(head[1], consoleColors[*entry.Severity])
+		}
+		head[2] = consolePaint(head[2], consoleDim)
+		for i, line := range lines {
+			lines[i] = consoleKeys.ReplaceAllString(line, "${1}\x1b["+consoleKey+"m${2}"+consoleReset+"=")
+		}
+	}
+
+	// done
+	var b strings.Builder
+	b.WriteString(strings.Join(head, " "))
+	b.WriteString(" ")
+	b.WriteString(strings.Join(lines, "\n"+indent))
+	for _, block := range blocks {
+		b.WriteString("\n" + indent + block)
+	}
+	if len(entry.Stack) > 0 {
+		stack := strings.TrimSuffix(entry.Stack.String(), "\n")
+		if con.color {
+			stack = consolePaint(stack, consoleDim)
+		}
+		b.WriteString("\n" + stack)
+	}
+	return b.String(), nil
+}
+
+// endregion: encoder
+// region: helpers
+
+func consoleOf(c *Ch) console {
+	con := console{}
+	if c.Config.Width != nil {
+		con.width = *c.Config.Width
+	}
+	if c.File == nil {
+		return con
+	}
+	if width, ok := terminalWidth(c.File); ok {
+		con.tty = true
+		con.color = os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+		if con.width == 0 {
+			con.width = width
+		}
+	}
+	return con
+}
+
+func consolePaint(s string, sgr string) string {
+	return "\x1b[" + sgr + "m" + s + consoleReset
+}
+
+func consolePad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// consoleLeft keeps the end, the line number is more telling than the beginning of a long file name
+
+func consoleLeft(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return "…" + string(r[len(r)-width+1:])
+}
+
+func consoleTruncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width-1]) + "…"
+}
+
+// consoleWrap breaks at spaces where it can, and in the middle of words longer than the width
+
+func consoleWrap(s string, width int) []string {
+	lines := make([]string, 0, 1)
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := make([]rune, 0, width)
+		for _, word := range strings.Split(paragraph, " ") {
+			w := []rune(word)
+			if len(line) > 0 && len(line)+1+len(w) > width {
+				lines = append(lines, string(line))
+				line = line[:0]
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			for len(line)+len(w) > width {
+				cut := width - len(line)
+				lines = append(lines, string(append(line, w[:cut]...)))
+				line, w = line[:0], w[cut:]
+			}
+			line = append(line, w...)
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// consoleBlock pretty prints structs, maps and slices, anything that can say it better itself (errors, Stringers, time) stays in line
+
+func consoleBlock(v interface{}, indent string) (string, bool) {
+	switch v.(type) {
+	case nil, error, fmt.Stringer, []byte:
+		return "", false
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return consolePretty(rv, indent, 0), true
+	}
+	return "", false
+}
+
+func consolePretty(v reflect.Value, indent string, depth int) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	if depth > consoleDepth {
+		return "…"
+	}
+	if v.CanInterface() {
+		switch i := v.Interface().(type) {
+		case error:
+			return strconv.Quote(i.Error())
+		case fmt.Stringer:
+			if v.Kind() != reflect.Ptr || !v.IsNil() {
+				return i.String()
+			}
+		}
+	}
+
+	inner := indent + "  "
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "nil"
+		}
+		prefix := ""
+		if v.Kind() == reflect.Ptr {
+			prefix = "&"
+		}
+		return prefix + consolePretty(v.Elem(), indent, depth+1)
+	case reflect.Struct:
+		if v.NumField() == 0 {
+			return v.Type().String() + "{}"
+		}
+		var b strings.Builder
+		b.WriteString(v.Type().String() + "{\n")
+		for i := 0; i < v.NumField(); i++ {
+			b.WriteString(inner + v.Type().Field(i).Name + ": " + consolePretty(v.Field(i), inner, depth+1) + ",\n")
+		}
+		b.WriteString(indent + "}")
+		return b.String()
+	case reflect.Map:
+		if v.Len() == 0 {
+			return v.Type().String() + "{}"
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		var b strings.Builder
+		b.WriteString(v.Type().String() + "{\n")
+		for _, k := range keys {
+			b.WriteString(inner + consolePretty(k, inner, depth+1) + ": " + consolePretty(v.MapIndex(k), inner, depth+1) + ",\n")
+		}
+		b.WriteString(indent + "}")
+		return b.String()
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return "nil"
+		}
+		if v.Len() == 0 {
+			return v.Type().String() + "{}"
+		}
+		var b strings.Builder
+		b.WriteString(v.Type().String() + "{\n")
+		for i := 0; i < v.Len(); i++ {
+			b.WriteString(inner + consolePretty(v.Index(i), inner, depth+1) + ",\n")
+		}
+		b.WriteString(indent + "}")
+		return b.String()
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if v.IsNil() {
+			return "nil"
+		}
+		return v.Type().String()
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// endregion: helpers
+// region: hr
+
+// HR writes a horizontal rule, with an optional title in it, to a channel with EncoderConsole (the rest ignore it),
+// as wide as ChConfig.Width, the terminal or 80 characters, in this order
+
+func (c *Ch) HR(title ...interface{}) error {
+	if c.Encoder != &EncoderConsole || c.Inst == nil {
+		return nil
+	}
+	if c.queue != nil {
+		c.queue.flush(context.Background()) // after what's been logged so far
+	}
+
+	con := consoleOf(c)
+	width := con.width
+	if width <= 0 {
+		width = 80
+	}
+	line := "-"
+	if con.tty {
+		line = "─"
+	}
+	rule := strings.Repeat(line, width)
+	if len(title) > 0 {
+		t := " " + strings.TrimSpace(fmt.Sprintln(title...)) + " "
+		rest := width - 2 - utf8.RuneCountInString(t)
+		if rest < 0 {
+			rest = 0
+		}
+		rule = strings.Repeat(line, 2) + t + strings.Repeat(line, rest)
+	}
+	if con.color {
+		rule = consolePaint(rule, consoleDim)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, e := io.WriteString(c.Inst.Writer(), rule+"\n")
+	return e
+}
+
+func (l *Logger) HR(title ...interface{}) *[]error {
+	es := make([]error, 0)
+	for _, c := range l.Channels() {
+		if e := c.HR(title...); e != nil {
+			es = append(es, e)
+		}
+	}
+	if len(es) == 0 {
+		return nil
+	}
+	return &es
+}
+
+// endregion: hr
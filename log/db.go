@@ -0,0 +1,124 @@
+// region: packages
+
+package log
+
+import (
+	"database/sql"
+	"fmt"
+	"log/syslog"
+	"regexp"
+	"strings"
+)
+
+// endregion: packages
+// region: types
+
+// DbConn is satisfied by *db.Db, log can't import db directly since db already imports log
+
+type DbConn interface {
+	Conn() *sql.DB
+	Driver() string
+}
+
+// endregion: types
+// region: defaults
+
+var dbTable = "log"
+var dbTableRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var dbCreateTable = map[string]string{
+	"mysql": `CREATE TABLE IF NOT EXISTS %s (
+				id			BIGINT			NOT NULL AUTO_INCREMENT PRIMARY KEY,
+				ts			DATETIME(6)		NOT NULL,
+				severity	TINYINT			NULL,
+				label		VARCHAR(32)		NULL,
+				file		VARCHAR(255)	NOT NULL,
+				line		INT				NOT NULL,
+				func		VARCHAR(255)	NOT NULL,
+				message		TEXT			NOT NULL
+			);`,
+	"postgres": `CREATE TABLE IF NOT EXISTS %s (
+				id			BIGSERIAL		NOT NULL PRIMARY KEY,
+				ts			TIMESTAMP(6)	NOT NULL,
+				severity	SMALLINT		NULL,
+				label		VARCHAR(32)		NULL,
+				file		VARCHAR(255)	NOT NULL,
+				line		INTEGER			NOT NULL,
+				func		VARCHAR(255)	NOT NULL,
+				message		TEXT			NOT NULL
+			);`,
+	"sqlite3": `CREATE TABLE IF NOT EXISTS %s (
+				id			INTEGER			NOT NULL PRIMARY KEY AUTOINCREMENT,
+				ts			DATETIME		NOT NULL,
+				severity	INTEGER			NULL,
+				label		VARCHAR(32)		NULL,
+				file		VARCHAR(255)	NOT NULL,
+				line		INTEGER			NOT NULL,
+				func		VARCHAR(255)	NOT NULL,
+				message		TEXT			NOT NULL
+			);`,
+}
+
+var dbInsert = map[string]string{
+	"mysql":    `INSERT INTO %s (ts, severity, label, file, line, func, message) VALUES (?, ?, ?, ?, ?, ?, ?);`,
+	"postgres": `INSERT INTO %s (ts, severity, label, file, line, func, message) VALUES ($1, $2, $3, $4, $5, $6, $7);`,
+	"sqlite3":  `INSERT INTO %s (ts, severity, label, file, line, func, message) VALUES (?, ?, ?, ?, ?, ?, ?);`,
+}
+
+// endregion: defaults
+// region: init
+
+func (c *Ch) dbInit() error {
+	if c.Config.Db == nil || c.Config.Db.Conn() == nil {
+		return ErrInvalidDb
+	}
+	table := *c.Config.DbTable
+	if !dbTableRegexp.MatchString(table) {
+		return fmt.Errorf("%s: %s", ErrInvalidDbTable, table)
+	}
+
+	driver := c.Config.Db.Driver()
+	create, ok := dbCreateTable[driver]
+	if !ok {
+		return fmt.Errorf("%s: %s", ErrInvalidDb, driver)
+	}
+
+	// statements go to Conn() directly instead of db.Exec(), which logs every statement, and if db.Config.Logger happens to be the logger this channel belongs to, that would loop forever
+	if _, e := c.Config.Db.Conn().Exec(fmt.Sprintf(create, table)); e != nil {
+		return e
+	}
+
+	c.Db = c.Config.Db
+	c.dbInsert = fmt.Sprintf(dbInsert[driver], table)
+	return nil
+}
+
+// endregion: init
+// region: output
+
+func (c *Ch) dbOut(s ...interface{}) error {
+
+	// severity and label go to separate columns, so it is not passed to the encoder, both are NULL without one (eg. With(...).Out())
+	var severity sql.NullInt32
+	var label sql.NullString
+	if len(s) > 0 {
+		if p, ok := s[0].(syslog.Priority); ok {
+			labels := *c.Config.SeverityLabels
+			severity = sql.NullInt32{Int32: int32(p), Valid: true}
+			label = sql.NullString{String: strings.Trim(labels[p], " :_"), Valid: true}
+			s = s[1:]
+		}
+	}
+
+	msg, e := Encoder(*c.Encoder)(c, s...)
+	if e != nil {
+		return e
+	}
+
+	// see dbInit() why not db.Exec()
+	caller := c.Entry().Caller
+	_, e = c.Db.Conn().Exec(c.dbInsert, c.Entry().Time.UTC(), severity, label, caller.File, caller.Line, caller.Function, msg)
+	return e
+}
+
+// endregion: output
@@ -0,0 +1,162 @@
+// region: packages
+
+package log
+
+import (
+	"fmt"
+	"sort"
+)
+
+// endregion: packages
+// region: types
+
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+type Fields []Field
+
+// endregion: types
+// region: constructors
+
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// NewFields takes key/value pairs, as well as Field, Fields and map[string]interface{} as they are, a key without value is kept with nil
+
+func NewFields(kv ...interface{}) (fields Fields) {
+	fields = make(Fields, 0, len(kv)/2)
+	for i := 0; i < len(kv); i++ {
+		switch v := kv[i].(type) {
+		case Field:
+			fields = append(fields, v)
+		case Fields:
+			fields = append(fields, v...)
+		case map[string]interface{}:
+			fields = append(fields, mapFields(v)...)
+		default:
+			f := Field{Key: fmt.Sprintf("%v", v)}
+			if i+1 < len(kv) {
+				i++
+				f.Value = kv[i]
+			}
+			fields = append(fields, f)
+		}
+	}
+	return
+}
+
+func (f Field) String() string {
+	return fmt.Sprintf("%s=%+v", f.Key, f.Value)
+}
+
+// endregion: constructors
+// region: helpers
+
+// splitFields separates Field, Fields and map[string]interface{} arguments from the rest
+
+func splitFields(n []interface{}) (args []interface{}, fields Fields) {
+	args = make([]interface{}, 0, len(n))
+	for _, v := range n {
+		switch v := v.(type) {
+		case Field:
+			fields = append(fields, v)
+		case Fields:
+			fields = append(fields, v...)
+		case map[string]interface{}:
+			fields = append(fields, mapFields(v)...)
+		default:
+			args = append(args, v)
+		}
+	}
+	return
+}
+
+// pairFields turns a string followed by a value into a field, like NewFields() does for With(), the rest is left as it is (eg. a trailing string)
+
+func pairFields(n []interface{}) (args []interface{}, fields Fields) {
+	args = make([]interface{}, 0, len(n))
+	for i := 0; i < len(n); i++ {
+		if key, ok := n[i].(string); ok && i+1 < len(n) {
+			fields = append(fields, Field{Key: key, Value: n[i+1]})
+			i++
+			continue
+		}
+		args = append(args, n[i])
+	}
+	return
+}
+
+// maps are sorted by key, otherwise the output would change from entry to entry
+
+func mapFields(m map[string]interface{}) Fields {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make(Fields, 0, len(m))
+	for _, k := range keys {
+		fields = append(fields, Field{Key: k, Value: m[k]})
+	}
+	return fields
+}
+
+func joinFields(a Fields, b Fields) Fields {
+	fields := make(Fields, 0, len(a)+len(b))
+	fields = append(fields, a...)
+	return append(fields, b...)
+}
+
+// endregion: helpers
+// region: with
+
+// With returns a copy of the channel, which attaches the fields to every entry, the underlying output is shared
+
+func (c *Ch) With(kv ...interface{}) *Ch {
+	view := *c
+	view.fields = joinFields(c.fields, NewFields(kv...))
+	return &view
+}
+
+// With returns a logger sharing the channels of l, which attaches the fields to every entry
+
+func (l *Logger) With(kv ...interface{}) *Logger {
+	l.lazy()
+	return &Logger{
+		chs:    l.chs,
+		fields: joinFields(l.fields, NewFields(kv...)),
+		hooks:  l.hooks,
+		scope:  l.scope,
+	}
+}
+
+// With does the same for anything Out() accepts, the rest (eg. nil) is returned as it is
+
+func With(c interface{}, kv ...interface{}) interface{} {
+	switch c := c.(type) {
+	case Ch:
+		return c.With(kv...)
+	case *Ch:
+		return c.With(kv...)
+	case Logger:
+		return c.With(kv...)
+	case *Logger:
+		return c.With(kv...)
+	default:
+		return c
+	}
+}
+
+// Fields returns the context fields, during Out() including those of the logger
+
+func (c *Ch) Fields() Fields {
+	if c.entry != nil {
+		return joinFields(c.entry.Fields, nil)
+	}
+	return joinFields(c.fields, nil)
+}
+
+// endregion: with
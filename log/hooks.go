@@ -0,0 +1,244 @@
+// region: packages
+
+package log
+
+import (
+	"fmt"
+	"log/syslog"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// endregion: packages
+// region: types
+
+type Filter func(e *Entry) bool
+
+// Hook is run on every entry matching Filter (or all of them if it's nil)
+//
+// Pre runs before the entry is written, it can change the entry in place and returns what should be written instead: nothing drops it, more than one fans it out
+// Post runs after the entry has been written (by a worker, if the channel is async), err is the outcome of the write
+
+type Hook struct {
+	Filter Filter
+	Pre    func(e *Entry) []*Entry
+	Post   func(e *Entry, err error)
+}
+
+type hookChain struct {
+	mu   sync.RWMutex
+	list []Hook
+}
+
+// endregion: types
+// region: chain
+
+func newHookChain(hooks []Hook) *hookChain {
+	h := hookChain{}
+	h.list = append(h.list, hooks...)
+	return &h
+}
+
+func (h *hookChain) add(hooks ...Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.list = append(h.list[:len(h.list):len(h.list)], hooks...)
+}
+
+func (h *hookChain) snapshot() []Hook {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.list
+}
+
+func runPre(hooks []Hook, e *Entry) []*Entry {
+	entries := []*Entry{e}
+	for _, hook := range hooks {
+		if hook.Pre == nil {
+			continue
+		}
+		next := make([]*Entry, 0, len(entries))
+		for _, entry := range entries {
+			if hook.Filter != nil && !hook.Filter(entry) {
+				next = append(next, entry)
+				continue
+			}
+			next = append(next, hook.Pre(entry)...)
+		}
+		entries = next
+	}
+	return entries
+}
+
+func runPost(hooks []Hook, e *Entry, err error) {
+	for _, hook := range hooks {
+		if hook.Post == nil || (hook.Filter != nil && !hook.Filter(e)) {
+			continue
+		}
+		hook.Post(e, err)
+	}
+}
+
+// AddHook appends to the chain of the channel, shared by the copies made by With()
+
+func (c *Ch) AddHook(hooks ...Hook) {
+	c.hooks.add(hooks...)
+}
+
+// AddHook appends to the chain of the logger, run once per entry before it is handed to the channels
+
+func (l *Logger) AddHook(hooks ...Hook) {
+	l.lazy()
+	l.hooks.add(hooks...)
+}
+
+// endregion: chain
+// region: entry
+
+func (e *Entry) clone() *Entry {
+	c := *e
+	c.Args = append([]interface{}{}, e.Args...)
+	c.Fields = joinFields(e.Fields, nil)
+	if e.Severity != nil {
+		severity := *e.Severity
+		c.Severity = &severity
+	}
+	return &c
+}
+
+// Message is the args (without fields) as text, for filters and the like
+
+func (e *Entry) Message() string {
+	args, _ := splitFields(e.Args)
+	s := make([]string, 0, len(args))
+	for _, v := range args {
+		s = append(s, fmt.Sprintf("%+v", v))
+	}
+	return strings.Join(s, " ")
+}
+
+func (e *Entry) SetSeverity(p syslog.Priority) {
+	e.Severity = &p
+}
+
+// endregion: entry
+// region: filters
+
+// FilterSeverity matches entries at p or above (that is numerically p or less)
+
+func FilterSeverity(p syslog.Priority) Filter {
+	return func(e *Entry) bool {
+		return e.Severity != nil && *e.Severity <= p
+	}
+}
+
+// FilterPackage matches the package of the caller, or of the helper it logged through (see Entry.Origin), against path.Match() patterns,
+// eg. "github.com/SandorMiskey/TEx-kit/*", patterns without a slash (like "db" or "main") match the last element only
+
+func FilterPackage(patterns ...string) Filter {
+	return func(e *Entry) bool {
+		return matchPackage(packageOf(e.Caller.Function), patterns...) ||
+			e.Origin.Function != "" && matchPackage(packageOf(e.Origin.Function), patterns...)
+	}
+}
+
+func FilterMessage(substr string) Filter {
+	return func(e *Entry) bool {
+		return strings.Contains(e.Message(), substr)
+	}
+}
+
+func FilterMessageRegexp(re *regexp.Regexp) Filter {
+	return func(e *Entry) bool {
+		return re.MatchString(e.Message())
+	}
+}
+
+func FilterAll(filters ...Filter) Filter {
+	return func(e *Entry) bool {
+		for _, f := range filters {
+			if !f(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func FilterAny(filters ...Filter) Filter {
+	return func(e *Entry) bool {
+		for _, f := range filters {
+			if f(e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func FilterNot(f Filter) Filter {
+	return func(e *Entry) bool {
+		return !f(e)
+	}
+}
+
+func matchPackage(pkg string, patterns ...string) bool {
+	for _, pattern := range patterns {
+		name := pkg
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(pkg)
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// endregion: filters
+// region: hooks
+
+func HookDrop(f Filter) Hook {
+	return Hook{
+		Filter: f,
+		Pre:    func(e *Entry) []*Entry { return nil },
+	}
+}
+
+// HookFanOut writes a copy of the matching entries to dst (a Ch or Logger) as well, dst must not lead back here
+
+func HookFanOut(dst interface{}, f Filter) Hook {
+	return Hook{
+		Filter: f,
+		Pre: func(e *Entry) []*Entry {
+			switch dst := dst.(type) {
+			case *Ch:
+				dst.dispatch(e.clone())
+			case *Logger:
+				dst.dispatch(e.clone())
+			}
+			return []*Entry{e}
+		},
+	}
+}
+
+// HookCount counts the matching entries that were written successfully
+
+func HookCount(n *uint64, f Filter) Hook {
+	return Hook{
+		Filter: f,
+		Post: func(e *Entry, err error) {
+			if err == nil {
+				atomic.AddUint64(n, 1)
+			}
+		},
+	}
+}
+
+// endregion: hooks
@@ -0,0 +1,24 @@
+package log_test
+
+import (
+	"testing"
+
+	"github.com/SandorMiskey/TEx-kit/log"
+	"github.com/SandorMiskey/TEx-kit/log/testdata/helper"
+)
+
+func TestFilterPackageHelper(t *testing.T) {
+	ch := newMemory(t, log.ChConfig{})
+	ch.AddHook(log.HookDrop(log.FilterPackage("helper")))
+
+	helper.Out(ch, log.LOG_INFO, "via helper")
+	ch.Out(log.LOG_INFO, "direct")
+
+	entries := ch.Entries(nil)
+	if len(entries) != 1 || entries[0].Message() != "direct" {
+		t.Fatalf("got %d entries, want the direct one only", len(entries))
+	}
+	if !log.FilterPackage("log_test")(entries[0]) {
+		t.Error("the caller doesn't match its own package")
+	}
+}
@@ -0,0 +1,167 @@
+// region: packages
+
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// endregion: packages
+// region: types
+
+type handler struct {
+	l *Logger
+}
+
+type handlerChannel struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Severity  int    `json:"severity"`
+	Label     string `json:"label"`
+	Default   int    `json:"default"`
+	Overrides string `json:"overrides"`
+}
+
+type handlerRequest struct {
+	Channel   string  `json:"channel"`
+	Severity  string  `json:"severity"`
+	Overrides *string `json:"overrides"`
+	Reset     bool    `json:"reset"`
+}
+
+// endregion: types
+// region: handler
+
+// Handler lists the channels with their severity on GET, and changes them on POST or PUT, either as form values or as a json body:
+//
+//	{"channel": "stdout", "severity": "debug"} // one channel, severity by name or number
+//	{"severity": "warning"}                    // all channels
+//	{"overrides": "db=debug,cfg=warning"}      // per package or file of the caller, "" clears them
+//	{"channel": "stdout", "reset": true}       // back to ChConfig.Severity and ChConfig.Overrides
+//
+// There is no authentication whatsoever, mount it accordingly.
+
+func (l *Logger) Handler() http.Handler {
+	return &handler{l: l}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodPut:
+		req, e := h.request(r)
+		if e != nil {
+			h.error(w, http.StatusBadRequest, e)
+			return
+		}
+		if status, e := h.apply(req); e != nil {
+			h.error(w, status, e)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST, PUT")
+		h.error(w, http.StatusMethodNotAllowed, ErrInvalidMethod)
+		return
+	}
+	h.list(w)
+}
+
+func (h *handler) request(r *http.Request) (req handlerRequest, e error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		e = json.NewDecoder(r.Body).Decode(&req)
+		return
+	}
+	if e = r.ParseForm(); e != nil {
+		return
+	}
+	req.Channel = r.Form.Get("channel")
+	req.Severity = r.Form.Get("severity")
+	if _, ok := r.Form["overrides"]; ok {
+		v := r.Form.Get("overrides")
+		req.Overrides = &v
+	}
+	if v := r.Form.Get("reset"); v != "" {
+		req.Reset, e = strconv.ParseBool(v)
+	}
+	return
+}
+
+func (h *handler) apply(req handlerRequest) (int, error) {
+	targets := h.l.Channels()
+	if req.Channel != "" {
+		c := h.l.Get(req.Channel)
+		if c == nil {
+			return http.StatusNotFound, ErrChannelNotFound
+		}
+		targets = []*Ch{c}
+	}
+
+	if req.Reset {
+		for _, c := range targets {
+			if e := c.ResetSeverity(); e != nil {
+				return http.StatusInternalServerError, e
+			}
+			if e := c.ResetOverrides(); e != nil {
+				return http.StatusInternalServerError, e
+			}
+		}
+		return http.StatusOK, nil
+	}
+
+	if req.Overrides != nil {
+		o, e := ParseOverrides(*req.Overrides)
+		if e != nil {
+			return http.StatusBadRequest, e
+		}
+		for _, c := range targets {
+			if e := c.SetOverrides(o); e != nil {
+				return http.StatusInternalServerError, e
+			}
+		}
+		if req.Severity == "" {
+			return http.StatusOK, nil
+		}
+	}
+
+	p, e := ParseSeverity(req.Severity)
+	if e != nil {
+		return http.StatusBadRequest, e
+	}
+	for _, c := range targets {
+		if e := c.SetSeverity(p); e != nil {
+			return http.StatusInternalServerError, e
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// endregion: handler
+// region: responses
+
+func (h *handler) list(w http.ResponseWriter) {
+	list := make([]handlerChannel, 0)
+	for _, c := range h.l.Channels() {
+		p := c.Severity()
+		list = append(list, handlerChannel{
+			Name:      c.Name(),
+			Type:      c.Type.String(),
+			Severity:  int(p),
+			Label:     SeverityNames[p],
+			Default:   int(*c.Config.Severity),
+			Overrides: c.Overrides().String(),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *handler) error(w http.ResponseWriter, status int, e error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": e.Error()})
+}
+
+// endregion: responses
@@ -0,0 +1,154 @@
+// region: packages
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// endregion: packages
+// region: encoder
+
+// one object per line, `time`, `severity`, `label`, `caller`, `channel`, `msg`, `args` and `stack` are reserved (plus `seq`, `prev` and `hash` on ChAudit),
+// colliding fields are prefixed with an underscore. Field, Fields and map[string]interface{} args are fields, the first of the rest is `msg`,
+// the ones after it are paired up like With() does (Out(p, "msg", "user", 42) is "user":42), what can't be (a value without a string key before it,
+// or a trailing key) goes to `args`
+
+var EncoderJSON Encoder = func(c *Ch, n ...interface{}) (s string, e error) {
+
+	// entry is only present when called from Out()
+	entry := c.Entry()
+	if entry == nil {
+		entry = &Entry{Time: time.Now()}
+	}
+	if len(n) > 0 && entry.Severity != nil && n[0] == *entry.Severity {
+		n = n[1:]
+	}
+	args, fields := splitFields(n)
+	fields = joinFields(c.Fields(), fields)
+
+	// reserved keys
+	o := jsonObject{}
+	o.set("time", entry.Time.UTC().Format(time.RFC3339Nano))
+	if entry.Severity != nil {
+		o.set("severity", int(*entry.Severity))
+		o.set("label", SeverityNames[*entry.Severity])
+	}
+	if entry.Caller.File != "" {
+		o.set("caller", map[string]interface{}{
+			"file":     entry.Caller.File,
+			"line":     entry.Caller.Line,
+			"function": entry.Caller.Function,
+		})
+	}
+	o.set("channel", c.Name())
+	if len(args) > 0 {
+		o.set("msg", fmt.Sprintf("%+v", args[0]))
+	}
+	if len(args) > 1 {
+		unpaired, pairs := pairFields(args[1:])
+		fields = joinFields(fields, pairs)
+		if len(unpaired) > 0 {
+			rest := make([]interface{}, 0, len(unpaired))
+			for _, v := range unpaired {
+				rest = append(rest, jsonValue(v))
+			}
+			o.set("args", rest)
+		}
+	}
+	if len(entry.Stack) > 0 {
+		frames := make([]interface{}, 0, len(entry.Stack))
+		for _, frame := range entry.Stack {
+			frames = append(frames, map[string]interface{}{
+				"file":     frame.File,
+				"line":     frame.Line,
+				"function": frame.Function,
+			})
+		}
+		o.set("stack", frames)
+	}
+	reserved := append([]string{}, o.keys...)
+	if c.Type == ChAudit {
+		reserved = append(reserved, auditKeys...) // the links are appended after the fields
+	}
+
+	// fields
+	for _, f := range fields {
+		key := f.Key
+		for _, k := range reserved {
+			if k == key {
+				key = "_" + key
+				break
+			}
+		}
+		o.set(key, jsonValue(f.Value))
+	}
+
+	// encode
+	b, e := o.marshal()
+	return string(b), e
+}
+
+// endregion: encoder
+// region: helpers
+
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *jsonObject) set(k string, v interface{}) {
+	if o.values == nil {
+		o.values = make(map[string]interface{})
+	}
+	if _, ok := o.values[k]; !ok {
+		o.keys = append(o.keys, k)
+	}
+	o.values[k] = v
+}
+
+// keeps the order of the keys, unlike a map would
+
+func (o *jsonObject) marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		vb, e := json.Marshal(o.values[k])
+		if e != nil {
+			vb, _ = json.Marshal(fmt.Sprintf("%+v", o.values[k]))
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// errors would end up as {}, and anything json can't handle as a string
+
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case error:
+		return v.Error()
+	case json.Marshaler:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	if _, e := json.Marshal(v); e != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+	return v
+}
+
+// endregion: helpers
@@ -0,0 +1,35 @@
+package log_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/SandorMiskey/TEx-kit/log"
+)
+
+func TestEncoderJSONPairs(t *testing.T) {
+	for _, tc := range []struct {
+		args []interface{}
+		want string // the part after "channel"
+	}{
+		{[]interface{}{"user logged in", "user", "bob", "id", 42}, `"msg":"user logged in","user":"bob","id":42`},
+		{[]interface{}{"took", 1.5, "ms"}, `"msg":"took","args":[1.5,"ms"]`},
+		{[]interface{}{"failed", "err", errors.New("boom"), "retry"}, `"msg":"failed","args":["retry"],"err":"boom"`},
+		{[]interface{}{"mixed", log.F("a", 1), "b", 2}, `"msg":"mixed","a":1,"b":2`},
+		{[]interface{}{"reserved", "msg", "x", "time", "y"}, `"msg":"reserved","_msg":"x","_time":"y"`},
+	} {
+		o, e := log.EncoderJSON(&log.Ch{Config: log.ChDefaults}, tc.args...)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !json.Valid([]byte(o)) {
+			t.Errorf("invalid json: %s", o)
+		}
+		i := strings.Index(o, `"msg"`)
+		if got := strings.TrimSuffix(o[i:], "}"); got != tc.want {
+			t.Errorf("%v: %s, want %s", tc.args, got, tc.want)
+		}
+	}
+}
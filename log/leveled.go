@@ -0,0 +1,162 @@
+// region: packages
+
+package log
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// endregion: packages
+// region: helpers
+
+func leveled(p syslog.Priority, s []interface{}) []interface{} {
+	return append([]interface{}{p}, s...)
+}
+
+// leveledf formats the args, fields among them are kept as fields
+
+func leveledf(p syslog.Priority, format string, a []interface{}) []interface{} {
+	args, fields := splitFields(a)
+	s := []interface{}{p, fmt.Sprintf(format, args...)}
+	for _, f := range fields {
+		s = append(s, f)
+	}
+	return s
+}
+
+// endregion: helpers
+// region: ch
+
+func (c *Ch) Emerg(s ...interface{}) error {
+	return c.Out(leveled(LOG_EMERG, s)...)
+}
+
+func (c *Ch) Emergf(format string, a ...interface{}) error {
+	return c.Out(leveledf(LOG_EMERG, format, a)...)
+}
+
+func (c *Ch) Alert(s ...interface{}) error {
+	return c.Out(leveled(LOG_ALERT, s)...)
+}
+
+func (c *Ch) Alertf(format string, a ...interface{}) error {
+	return c.Out(leveledf(LOG_ALERT, format, a)...)
+}
+
+func (c *Ch) Crit(s ...interface{}) error {
+	return c.Out(leveled(LOG_CRIT, s)...)
+}
+
+func (c *Ch) Critf(format string, a ...interface{}) error {
+	return c.Out(leveledf(LOG_CRIT, format, a)...)
+}
+
+func (c *Ch) Err(s ...interface{}) error {
+	return c.Out(leveled(LOG_ERR, s)...)
+}
+
+func (c *Ch) Errf(format string, a ...interface{}) error {
+	return c.Out(leveledf(LOG_ERR, format, a)...)
+}
+
+func (c *Ch) Warning(s ...interface{}) error {
+	return c.Out(leveled(LOG_WARNING, s)...)
+}
+
+func (c *Ch) Warningf(format string, a ...interface{}) error {
+	return c.Out(leveledf(LOG_WARNING, format, a)...)
+}
+
+func (c *Ch) Notice(s ...interface{}) error {
+	return c.Out(leveled(LOG_NOTICE, s)...)
+}
+
+func (c *Ch) Noticef(format string, a ...interface{}) error {
+	return c.Out(leveledf(LOG_NOTICE, format, a)...)
+}
+
+func (c *Ch) Info(s ...interface{}) error {
+	return c.Out(leveled(LOG_INFO, s)...)
+}
+
+func (c *Ch) Infof(format string, a ...interface{}) error {
+	return c.Out(leveledf(LOG_INFO, format, a)...)
+}
+
+func (c *Ch) Debug(s ...interface{}) error {
+	return c.Out(leveled(LOG_DEBUG, s)...)
+}
+
+func (c *Ch) Debugf(format string, a ...interface{}) error {
+	return c.Out(leveledf(LOG_DEBUG, format, a)...)
+}
+
+// endregion: ch
+// region: logger
+
+func (l *Logger) Emerg(s ...interface{}) *[]error {
+	return l.Out(leveled(LOG_EMERG, s)...)
+}
+
+func (l *Logger) Emergf(format string, a ...interface{}) *[]error {
+	return l.Out(leveledf(LOG_EMERG, format, a)...)
+}
+
+func (l *Logger) Alert(s ...interface{}) *[]error {
+	return l.Out(leveled(LOG_ALERT, s)...)
+}
+
+func (l *Logger) Alertf(format string, a ...interface{}) *[]error {
+	return l.Out(leveledf(LOG_ALERT, format, a)...)
+}
+
+func (l *Logger) Crit(s ...interface{}) *[]error {
+	return l.Out(leveled(LOG_CRIT, s)...)
+}
+
+func (l *Logger) Critf(format string, a ...interface{}) *[]error {
+	return l.Out(leveledf(LOG_CRIT, format, a)...)
+}
+
+func (l *Logger) Err(s ...interface{}) *[]error {
+	return l.Out(leveled(LOG_ERR, s)...)
+}
+
+func (l *Logger) Errf(format string, a ...interface{}) *[]error {
+	return l.Out(leveledf(LOG_ERR, format, a)...)
+}
+
+func (l *Logger) Warning(s ...interface{}) *[]error {
+	return l.Out(leveled(LOG_WARNING, s)...)
+}
+
+func (l *Logger) Warningf(format string, a ...interface{}) *[]error {
+	return l.Out(leveledf(LOG_WARNING, format, a)...)
+}
+
+func (l *Logger) Notice(s ...interface{}) *[]error {
+	return l.Out(leveled(LOG_NOTICE, s)...)
+}
+
+func (l *Logger) Noticef(format string, a ...interface{}) *[]error {
+	return l.Out(leveledf(LOG_NOTICE, format, a)...)
+}
+
+func (l *Logger) Info(s ...interface{}) *[]error {
+	return l.Out(leveled(LOG_INFO, s)...)
+}
+
+func (l *Logger) Infof(format string, a ...interface{}) *[]error {
+	return l.Out(leveledf(LOG_INFO, format, a)...)
+}
+
+func (l *Logger) Debug(s ...interface{}) *[]error {
+	return l.Out(leveled(LOG_DEBUG, s)...)
+}
+
+func (l *Logger) Debugf(format string, a ...interface{}) *[]error {
+	return l.Out(leveledf(LOG_DEBUG, format, a)...)
+}
+
+// endregion: logger
@@ -0,0 +1,215 @@
+// region: packages
+
+package log
+
+import (
+	"fmt"
+	"log/syslog"
+	"sync"
+	"time"
+)
+
+// endregion: packages
+// region: types
+
+// Rate lets N entries through in every Per long window
+
+type Rate struct {
+	N   int
+	Per time.Duration
+}
+
+type RateLimits map[syslog.Priority]Rate
+
+type limiter struct {
+	mu sync.Mutex
+
+	ch     *Ch
+	dedup  time.Duration
+	limits RateLimits
+	rates  map[syslog.Priority]*rateState
+	seen   map[string]*dedupState
+	swept  time.Time
+}
+
+type dedupState struct {
+	count int
+	last  *Entry
+	start time.Time
+	timer *time.Timer
+}
+
+type rateState struct {
+	count   int
+	dropped int
+	start   time.Time
+}
+
+// endregion: types
+// region: constructor
+
+func newLimiter(c *Ch, dedup time.Duration, limits RateLimits) *limiter {
+	return &limiter{
+		ch:     c,
+		dedup:  dedup,
+		limits: limits,
+		rates:  make(map[syslog.Priority]*rateState),
+		seen:   make(map[string]*dedupState),
+	}
+}
+
+func (c *Ch) limiting() bool {
+	return *c.Config.Dedup > 0 || len(*c.Config.RateLimits) > 0
+}
+
+// endregion: constructor
+// region: allow
+
+// allow tells if the entry should be written, crit and above always are
+//
+// The first of the identical entries (same caller, severity and message) within the dedup window goes through, the rest are counted, and the last of them is written with a "repeated" field once the window is over.
+// Entries over the rate limit of their severity are dropped, the next one let through gets a "suppressed" field.
+
+func (l *limiter) allow(e *Entry) bool {
+	if e.Severity != nil && *e.Severity <= LOG_CRIT {
+		return true
+	}
+	now := time.Now()
+
+	if l.dedup > 0 {
+		ok, summary := l.once(e, now)
+		if summary != nil {
+			l.ch.send(summary)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return l.rate(e, now)
+}
+
+func (l *limiter) once(e *Entry, now time.Time) (bool, *Entry) {
+	severity := "-"
+	if e.Severity != nil {
+		severity = SeverityNames[*e.Severity]
+	}
+	key := fmt.Sprintf("%s:%d:%s:%s", e.Caller.File, e.Caller.Line, severity, e.Message())
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	st, ok := l.seen[key]
+	if ok && now.Sub(st.start) < l.dedup {
+		st.count++
+		st.last = e
+		if st.timer == nil {
+			st.timer = time.AfterFunc(st.start.Add(l.dedup).Sub(now), func() { l.expire(key, st) })
+		}
+		return false, nil
+	}
+
+	var summary *Entry
+	if ok {
+		summary = st.take()
+	}
+	l.seen[key] = &dedupState{start: now}
+	return true, summary
+}
+
+func (l *limiter) rate(e *Entry, now time.Time) bool {
+	if e.Severity == nil {
+		return true
+	}
+	r, ok := l.limits[*e.Severity]
+	if !ok || r.N <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	st, ok := l.rates[*e.Severity]
+	if !ok || now.Sub(st.start) >= r.Per {
+		dropped := 0
+		if ok {
+			dropped = st.dropped
+		}
+		st = &rateState{start: now}
+		l.rates[*e.Severity] = st
+		if dropped > 0 {
+			e.Fields = joinFields(e.Fields, Fields{F("suppressed", dropped)})
+		}
+	}
+	if st.count >= r.N {
+		st.dropped++
+		return false
+	}
+	st.count++
+	return true
+}
+
+// endregion: allow
+// region: dedup state
+
+// take returns the entry summing up the repeats and resets the counter, nil if there was none
+
+func (st *dedupState) take() *Entry {
+	if st.timer != nil {
+		st.timer.Stop()
+	}
+	if st.count == 0 {
+		return nil
+	}
+	summary := st.last.clone()
+	summary.Fields = joinFields(summary.Fields, Fields{F("repeated", st.count)})
+	st.count = 0
+	return summary
+}
+
+func (l *limiter) expire(key string, st *dedupState) {
+	l.mu.Lock()
+	if l.seen[key] == st {
+		delete(l.seen, key)
+	}
+	summary := st.take()
+	l.mu.Unlock()
+
+	if summary != nil {
+		l.ch.send(summary)
+	}
+}
+
+// sweep forgets the windows that are over and have nothing to report, the ones with repeats are left to their timers
+
+func (l *limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.dedup {
+		return
+	}
+	l.swept = now
+	for key, st := range l.seen {
+		if st.count == 0 && now.Sub(st.start) >= l.dedup {
+			delete(l.seen, key)
+		}
+	}
+}
+
+// close writes the pending summaries
+
+func (l *limiter) close() {
+	l.mu.Lock()
+	summaries := make([]*Entry, 0)
+	for key, st := range l.seen {
+		if summary := st.take(); summary != nil {
+			summaries = append(summaries, summary)
+		}
+		delete(l.seen, key)
+	}
+	l.mu.Unlock()
+
+	for _, summary := range summaries {
+		l.ch.send(summary)
+	}
+}
+
+// endregion: dedup state
@@ -3,14 +3,22 @@
 package log
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"log"
+	"log/slog"
 	"log/syslog"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // endregion: packages
@@ -23,8 +31,16 @@ type ChType int
 type SeverityLabels map[syslog.Priority]string
 
 type ChConfig struct {
-	// Db      interface{}
+	Addr           *string
+	Backoff        *time.Duration
+	BackoffMax     *time.Duration
+	BatchSize      *int
+	BatchWait      *time.Duration
+	BufferSize     *int
 	Bye            *string
+	Db             DbConn
+	DbTable        *string
+	Dedup          *time.Duration
 	Delimiter      *string
 	Depth          *int
 	Encoder        *Encoder
@@ -33,29 +49,101 @@ type ChConfig struct {
 	FileFlags      *int
 	FilePerm       *int
 	Flags          *int
+	Framing        *Framing
+	Fsync          *time.Duration
+	Gzip           *bool
+	Headers        http.Header
+	Hooks          []Hook
+	Hostname       *string
+	Index          *string
 	Mark           *string
+	MemorySize     *int
+	Name           *string
+	Network        *string
+	Overrides      *Overrides
 	Prefix         *string
+	PushFormat     *PushFormat
+	Queue          *int
+	QueuePolicy    *QueuePolicy
+	RateLimits     *RateLimits
+	Redact         *bool
+	Retries        *int
+	RotateCompress *bool
+	RotateEvery    *Rotation
+	RotateKeep     *int
+	RotateMaxAge   *time.Duration
+	RotateSize     *int64
+	ScopeSize      *int
+	ScopeTrigger   *syslog.Priority
 	Severity       *syslog.Priority
 	SeverityLabels *SeverityLabels
+	Slog           slog.Handler
+	Spool          *string
+	SpoolSize      *int64
+	StackTrace     *syslog.Priority
+	SyslogFormat   *SyslogFormat
+	TLS            *tls.Config
+	Tag            *string
+	Timeout        *time.Duration
 	Type           ChType
+	URL            *string
 	Welcome        *string
+	Width          *int
+	Workers        *int
+	Wrap           *bool
 }
 
 type Ch struct {
 	Config  ChConfig
+	Db      DbConn
 	Encoder *Encoder
 	File    *os.File
 	Inst    *log.Logger
 	// Inst interface{}
 	Type ChType
+
+	audit     *audit
+	dbInsert  string
+	entry     *Entry
+	fields    Fields
+	hooks     *hookChain // shared by the copies made by With()
+	limiter   *limiter
+	memory    *memory
+	mu        *sync.Mutex                // shared by the copies made by With()
+	overrides *atomic.Pointer[overrides] // shared by the copies made by With(), see SetOverrides()
+	queue     *queue
+	remote    *remote
+	rotator   *rotator
+	scope     *scope // see Scope()
+	severity  *int32 // shared by the copies made by With(), see SetSeverity()
+	shipper   *shipper
+	stream    *stream
+}
+
+type Entry struct {
+	Args     []interface{}
+	Caller   Frame
+	Fields   Fields
+	Origin   Frame // where the caller entered the helpers (see RegisterHelper), same as Caller without them
+	Severity *syslog.Priority
+	Stack    Frames
+	Time     time.Time
 }
 
 type LoggerConfig struct {
-	Ch []ChConfig
+	Ch []ChConfig `json:"channels"`
 }
 
 type Logger struct {
-	Ch []*Ch
+	chs    *channels // shared with the loggers derived by With()
+	fields Fields
+	hooks  *hookChain // shared with the loggers derived by With()
+	scope  *scope     // see Scope()
+}
+
+type channels struct {
+	mu   sync.RWMutex
+	list []*Ch
 }
 
 // endregion: types
@@ -67,8 +155,27 @@ const (
 	ChDb
 	ChFile
 	ChSyslog
+	ChSyslogRemote
+	ChSlog
+	ChMemory
+	ChNet
+	ChHTTP
+	ChAudit
 )
 
+var chTypeNames = map[ChType]string{
+	ChUndefined:    "undefined",
+	ChDb:           "db",
+	ChFile:         "file",
+	ChSyslog:       "syslog",
+	ChSyslogRemote: "syslog-remote",
+	ChSlog:         "slog",
+	ChMemory:       "memory",
+	ChNet:          "net",
+	ChHTTP:         "http",
+	ChAudit:        "audit",
+}
+
 // syslog priority
 const (
 	LOG_EMERG   = syslog.LOG_EMERG
@@ -103,30 +210,80 @@ const (
 	LOG_LOCAL7 = syslog.LOG_LOCAL7
 )
 
+// no stack trace for any severity, see ChConfig.StackTrace
+const StackOff syslog.Priority = -1
+
 // endregion: constants
 // region: messages
 
 var (
+	ErrAuditBroken            = errors.New("audit chain is broken")
+	ErrChannelExists          = errors.New("channel already exists")
+	ErrChannelNotFound        = errors.New("channel not found")
+	ErrInvalidAudit           = errors.New("invalid audit channel, it needs a file name, appended and not rotated")
+	ErrInvalidConfig          = errors.New("invalid config")
+	ErrInvalidDb              = errors.New("invalid db connection")
+	ErrInvalidDbTable         = errors.New("invalid db table name")
 	ErrInvalidFile            = errors.New("invalid file")
 	ErrInvalidLoggerOrChannel = errors.New("invalid logger or channel")
+	ErrInvalidMethod          = errors.New("invalid method")
+	ErrInvalidName            = errors.New("invalid channel name")
+	ErrInvalidNetwork         = errors.New("invalid network")
+	ErrInvalidOverride        = errors.New("invalid severity override")
+	ErrInvalidRotation        = errors.New("invalid rotation, it needs a file name")
 	ErrInvalidSeverity        = errors.New("invalid severity")
+	ErrInvalidSlogHandler     = errors.New("invalid slog handler")
+	ErrNotConnected           = errors.New("not connected, waiting to redial")
 	ErrNotImplementedYet      = errors.New("not implemented yet")
+	ErrPushFailed             = errors.New("push failed")
+	ErrSpoolFull              = errors.New("spool is full")
 	ErrTooManyParameters      = errors.New("too many parameters")
 )
 
 // endregion: messages
 // region: defaults
 
+var addr = "localhost:514"
+var backoff = time.Second
+var backoffmax = time.Minute
+var batchsize = 100
+var batchwait = time.Second
+var buffersize = 10000
 var bye = "logger is leaving..."
+var dbtable = dbTable
+var dedup = time.Duration(0)
 var delimiter = " -> "
 var depth = 0
 var facility = syslog.LOG_LOCAL0
 var fileflags = os.O_APPEND | os.O_CREATE | os.O_WRONLY
 var fileperm = 0640
 var flags = log.Ldate | log.Ltime | log.LUTC | log.Lshortfile
+var framing = FrameNewline
+var fsync = time.Duration(0)
+var gzipped = false
+var hostname = ""
+var index = "log"
 var mark = "logger was here..."
+var memorysize = 1000
+var network = "udp"
+var overriderules = Overrides{}
 var prefix = "==> "
+var pushformat = PushElastic
+var queuepolicy = QueueBlock
+var queuesize = 0
+var ratelimits = RateLimits{}
+var redacted = true
+var retries = 3
+var rotatecompress = false
+var rotateevery = RotateNever
+var rotatekeep = 0
+var rotatemaxage = time.Duration(0)
+var rotatesize = int64(0)
+var scopesize = 100
+var scopetrigger = LOG_ERR
 var severity = syslog.LOG_DEBUG
+var spool = ""
+var spoolsize = int64(0)
 var severityLabels SeverityLabels = map[syslog.Priority]string{
 	LOG_EMERG:   "__EMERG__: ",
 	LOG_ALERT:   "__ALERT__: ",
@@ -137,24 +294,90 @@ var severityLabels SeverityLabels = map[syslog.Priority]string{
 	LOG_INFO:    "__INFO__: ",
 	LOG_DEBUG:   "__DEBUG__: ",
 }
+var SeverityNames = map[syslog.Priority]string{ // plain names for structured encoders
+	LOG_EMERG:   "emerg",
+	LOG_ALERT:   "alert",
+	LOG_CRIT:    "crit",
+	LOG_ERR:     "err",
+	LOG_WARNING: "warning",
+	LOG_NOTICE:  "notice",
+	LOG_INFO:    "info",
+	LOG_DEBUG:   "debug",
+}
+var stacktrace = StackOff
+var syslogformat = RFC5424
+var tag = ""
+var timeout = 5 * time.Second
+var url = ""
 var welcome = os.Args[0] + " logger has been initiated"
+var width = 0
+var workers = 1
+var wrap = true
+
+// encoders that produce complete lines on their own, log.Logger's prefix and flags are off by default for them
+
+var rawEncoders = map[*Encoder]bool{
+	&EncoderConsole: true,
+	&EncoderJSON:    true,
+}
+var rawflags = 0
+var rawprefix = ""
 
 var ChDefaults = ChConfig{
+	Addr:           &addr,           // default remote address
+	Backoff:        &backoff,        // first wait before redialing a remote, doubled on each failure
+	BackoffMax:     &backoffmax,     // max wait before redialing a remote
+	BatchSize:      &batchsize,      // # of entries ChHTTP posts at once
+	BatchWait:      &batchwait,      // max wait before ChHTTP posts a batch that is not full
+	BufferSize:     &buffersize,     // # of entries ChHTTP keeps while the endpoint is slow or down, the oldest are dropped beyond that
 	Bye:            &bye,            // default exit msg
+	DbTable:        &dbtable,        // default table for ChDb
+	Dedup:          &dedup,          // window to collapse identical entries into one with a repeat count, 0 means off
 	Delimiter:      &delimiter,      // default delimiter
-	Depth:          &depth,          // default depth offset for Output()
+	Depth:          &depth,          // # of frames to skip above the caller, on top of this package and the helpers (see RegisterHelper())
 	Encoder:        &EncoderFlat,    // default encoder
 	Facility:       &facility,       // default syslog facility
 	File:           os.Stdout,       // default file
 	FileFlags:      &fileflags,      // default flags to OpenFile wrapping those of the underlying system
 	FilePerm:       &fileperm,       // default permissions for log files
 	Flags:          &flags,          // define which text to prefix to each log entry generated by the Logger
+	Framing:        &framing,        // how ChNet separates the entries on the wire (FrameNewline, FrameLength)
+	Fsync:          &fsync,          // ChAudit fsyncs after each entry if 0, otherwise at most this often (and on Flush and Close)
+	Gzip:           &gzipped,        // gzip the requests of ChHTTP
+	Hostname:       &hostname,       // hostname sent to remote syslog, os.Hostname() if empty
+	Index:          &index,          // elasticsearch index for ChHTTP with PushElastic
 	Mark:           &mark,           // default mark msg
+	MemorySize:     &memorysize,     // # of entries kept by ChMemory
+	Network:        &network,        // default network for remotes (tcp, udp, tls, unix...)
+	Overrides:      &overriderules,  // severity per package or file of the caller, like "db=debug,cfg=warning", see ParseOverrides()
 	Prefix:         &prefix,         // default output prefix
+	PushFormat:     &pushformat,     // payload of ChHTTP (PushElastic, PushLoki)
+	Queue:          &queuesize,      // size of the async queue, 0 means synchronous writes
+	QueuePolicy:    &queuepolicy,    // what to do when the queue is full (QueueBlock, QueueDropNewest, QueueDropOldest)
+	RateLimits:     &ratelimits,     // max # of entries per severity and period, crit and above are never limited
+	Redact:         &redacted,       // mask passwords, tokens and fields tagged `log:"secret"` before the entry is encoded, costs a pass of the patterns over each string and a copy of each struct arg
+	Retries:        &retries,        // # of times ChHTTP retries a batch (network errors, 429 and 5xx), backed off like the redials
+	RotateCompress: &rotatecompress, // gzip rotated files
+	RotateEvery:    &rotateevery,    // time based rotation (RotateNever, RotateHourly, RotateDaily)
+	RotateKeep:     &rotatekeep,     // # of rotated files to keep, 0 means all
+	RotateMaxAge:   &rotatemaxage,   // max age of rotated files, 0 means forever
+	RotateSize:     &rotatesize,     // size based rotation in bytes, 0 means off
+	ScopeSize:      &scopesize,      // max # of entries a scope holds back for the channel, the oldest are dropped
+	ScopeTrigger:   &scopetrigger,   // entries at or above this severity release what a scope held back
 	Severity:       &severity,       // default syslog severity
 	SeverityLabels: &severityLabels, // default labels for severities
+	Spool:          &spool,          // file to keep ChNet entries in while the remote is down, replayed after reconnecting, empty means off
+	SpoolSize:      &spoolsize,      // max size of the spool in bytes, 0 means unlimited
+	StackTrace:     &stacktrace,     // entries at or above this severity get the stack trace attached, StackOff means never
+	SyslogFormat:   &syslogformat,   // default remote syslog format (RFC5424 or RFC3164)
+	Tag:            &tag,            // app name sent to remote syslog, os.Args[0] if empty
+	Timeout:        &timeout,        // dial and write timeout for remotes, the request timeout of ChHTTP and how long its Close() tries to send what's left
 	Type:           ChFile,          // default Ch.Type
+	URL:            &url,            // endpoint of ChHTTP
 	Welcome:        &welcome,        // default mark msg
+	Width:          &width,          // max width of EncoderConsole lines, 0 means the width of the terminal, unlimited if it's not one
+	Workers:        &workers,        // # of goroutines serving the async queue, more than one won't keep the order
+	Wrap:           &wrap,           // EncoderConsole wraps the lines longer than the width, or truncates them if false
 }
 
 // endregion: defaults
@@ -162,17 +385,165 @@ var ChDefaults = ChConfig{
 
 func NewLogger() (l *Logger) {
 	return &Logger{
-		Ch: make([]*Ch, 0),
+		chs:   &channels{list: make([]*Ch, 0)},
+		hooks: newHookChain(nil),
+	}
+}
+
+// lazy makes the zero value usable, like var l log.Logger; l.NewCh("x"). It's a package level lock rather than a field,
+// since loggers are copied by value (eg. *NewLogger()), and the fields are only written once.
+
+var lazyMu sync.RWMutex
+
+func (l *Logger) lazy() {
+	lazyMu.RLock()
+	ok := l.chs != nil && l.hooks != nil
+	lazyMu.RUnlock()
+	if ok {
+		return
+	}
+
+	lazyMu.Lock()
+	defer lazyMu.Unlock()
+	if l.chs == nil {
+		l.chs = &channels{list: make([]*Ch, 0)}
+	}
+	if l.hooks == nil {
+		l.hooks = newHookChain(nil)
 	}
 }
 
 func (l *Logger) Close() (e error) {
-	for _, ch := range l.Ch {
+	l.lazy()
+	l.chs.mu.Lock()
+	list := l.chs.list
+	l.chs.list = make([]*Ch, 0)
+	l.chs.mu.Unlock()
+
+	for _, ch := range list {
 		e = ch.Close()
 	}
 	return e
 }
 
+// endregion: logger
+// region: channel management
+
+// channels are created and closed outside of the lock, so that loggers aren't blocked by dialing, welcome or bye
+
+func (l *Logger) NewCh(name string, cs ...ChConfig) (*Ch, error) {
+	l.lazy()
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+	if l.Get(name) != nil {
+		return nil, fmt.Errorf("%s: %s", ErrChannelExists, name)
+	}
+	if len(cs) == 0 {
+		cs = append(cs, ChDefaults)
+	}
+	if len(cs) > 1 {
+		return nil, ErrTooManyParameters
+	}
+	c := cs[0]
+	c.Name = &name
+
+	ch, e := NewCh(c)
+	if e != nil {
+		return nil, e
+	}
+
+	l.chs.mu.Lock()
+	for _, v := range l.chs.list {
+		if v.Name() == name {
+			l.chs.mu.Unlock()
+			ch.Close()
+			return nil, fmt.Errorf("%s: %s", ErrChannelExists, name)
+		}
+	}
+	l.chs.list = append(l.chs.list, ch)
+	l.chs.mu.Unlock()
+	return ch, nil
+}
+
+// Channels returns a snapshot of the channels in the order they were added
+
+func (l *Logger) Channels() []*Ch {
+	l.lazy()
+	l.chs.mu.RLock()
+	defer l.chs.mu.RUnlock()
+	list := make([]*Ch, len(l.chs.list))
+	copy(list, l.chs.list)
+	return list
+}
+
+func (l *Logger) Get(name string) *Ch {
+	l.lazy()
+	l.chs.mu.RLock()
+	defer l.chs.mu.RUnlock()
+	for _, ch := range l.chs.list {
+		if ch.Name() == name {
+			return ch
+		}
+	}
+	return nil
+}
+
+// Remove takes the channel out of the logger and closes it
+
+func (l *Logger) Remove(name string) error {
+	l.lazy()
+	l.chs.mu.Lock()
+	var ch *Ch
+	for k, v := range l.chs.list {
+		if v.Name() == name {
+			ch = v
+			l.chs.list = append(l.chs.list[:k:k], l.chs.list[k+1:]...)
+			break
+		}
+	}
+	l.chs.mu.Unlock()
+
+	if ch == nil {
+		return fmt.Errorf("%s: %s", ErrChannelNotFound, name)
+	}
+	return ch.Close()
+}
+
+// Replace swaps the channel for a new one created from c, keeping its name and position, and closes the old one
+
+func (l *Logger) Replace(name string, c ChConfig) (*Ch, error) {
+	l.lazy()
+	if l.Get(name) == nil {
+		return nil, fmt.Errorf("%s: %s", ErrChannelNotFound, name)
+	}
+	c.Name = &name
+	ch, e := NewCh(c)
+	if e != nil {
+		return nil, e
+	}
+
+	l.chs.mu.Lock()
+	var old *Ch
+	for k, v := range l.chs.list {
+		if v.Name() == name {
+			old = v
+			l.chs.list[k] = ch
+			break
+		}
+	}
+	l.chs.mu.Unlock()
+
+	if old == nil {
+		ch.Close()
+		return nil, fmt.Errorf("%s: %s", ErrChannelNotFound, name)
+	}
+	old.Close()
+	return ch, nil
+}
+
+// endregion: channel management
+
 // endregion: logger
 // region: destinations
 
@@ -193,9 +564,33 @@ func NewCh(cs ...ChConfig) (*Ch, error) {
 	// endregion: prepare
 	// region: check/set defaults
 
+	if c.Addr == nil {
+		c.Addr = ChDefaults.Addr
+	}
+	if c.Backoff == nil {
+		c.Backoff = ChDefaults.Backoff
+	}
+	if c.BackoffMax == nil {
+		c.BackoffMax = ChDefaults.BackoffMax
+	}
+	if c.BatchSize == nil {
+		c.BatchSize = ChDefaults.BatchSize
+	}
+	if c.BatchWait == nil {
+		c.BatchWait = ChDefaults.BatchWait
+	}
+	if c.BufferSize == nil {
+		c.BufferSize = ChDefaults.BufferSize
+	}
 	if c.Bye == nil {
 		c.Bye = ChDefaults.Bye
 	}
+	if c.DbTable == nil {
+		c.DbTable = ChDefaults.DbTable
+	}
+	if c.Dedup == nil {
+		c.Dedup = ChDefaults.Dedup
+	}
 	if c.Delimiter == nil {
 		c.Delimiter = ChDefaults.Delimiter
 	}
@@ -204,6 +599,9 @@ func NewCh(cs ...ChConfig) (*Ch, error) {
 	}
 	if c.Encoder == nil {
 		c.Encoder = ChDefaults.Encoder
+		if c.Type == ChHTTP || c.Type == ChAudit {
+			c.Encoder = &EncoderJSON
+		}
 	}
 	if c.File == nil || c.File == "" {
 		c.File = ChDefaults.File
@@ -219,12 +617,81 @@ func NewCh(cs ...ChConfig) (*Ch, error) {
 	}
 	if c.Flags == nil {
 		c.Flags = ChDefaults.Flags
+		if rawEncoders[c.Encoder] {
+			c.Flags = &rawflags
+		}
+	}
+	if c.Framing == nil {
+		c.Framing = ChDefaults.Framing
+	}
+	if c.Fsync == nil {
+		c.Fsync = ChDefaults.Fsync
+	}
+	if c.Gzip == nil {
+		c.Gzip = ChDefaults.Gzip
+	}
+	if c.Hostname == nil {
+		c.Hostname = ChDefaults.Hostname
+	}
+	if c.Index == nil {
+		c.Index = ChDefaults.Index
 	}
 	if c.Mark == nil {
 		c.Mark = ChDefaults.Mark
 	}
+	if c.MemorySize == nil {
+		c.MemorySize = ChDefaults.MemorySize
+	}
+	if c.Network == nil {
+		c.Network = ChDefaults.Network
+	}
+	if c.Overrides == nil {
+		c.Overrides = ChDefaults.Overrides
+	}
 	if c.Prefix == nil {
 		c.Prefix = ChDefaults.Prefix
+		if rawEncoders[c.Encoder] {
+			c.Prefix = &rawprefix
+		}
+	}
+	if c.PushFormat == nil {
+		c.PushFormat = ChDefaults.PushFormat
+	}
+	if c.Queue == nil {
+		c.Queue = ChDefaults.Queue
+	}
+	if c.QueuePolicy == nil {
+		c.QueuePolicy = ChDefaults.QueuePolicy
+	}
+	if c.RateLimits == nil {
+		c.RateLimits = ChDefaults.RateLimits
+	}
+	if c.Redact == nil {
+		c.Redact = ChDefaults.Redact
+	}
+	if c.Retries == nil {
+		c.Retries = ChDefaults.Retries
+	}
+	if c.RotateCompress == nil {
+		c.RotateCompress = ChDefaults.RotateCompress
+	}
+	if c.RotateEvery == nil {
+		c.RotateEvery = ChDefaults.RotateEvery
+	}
+	if c.RotateKeep == nil {
+		c.RotateKeep = ChDefaults.RotateKeep
+	}
+	if c.RotateMaxAge == nil {
+		c.RotateMaxAge = ChDefaults.RotateMaxAge
+	}
+	if c.RotateSize == nil {
+		c.RotateSize = ChDefaults.RotateSize
+	}
+	if c.ScopeSize == nil {
+		c.ScopeSize = ChDefaults.ScopeSize
+	}
+	if c.ScopeTrigger == nil {
+		c.ScopeTrigger = ChDefaults.ScopeTrigger
 	}
 	if c.Severity == nil {
 		c.Severity = ChDefaults.Severity
@@ -232,12 +699,46 @@ func NewCh(cs ...ChConfig) (*Ch, error) {
 	if c.SeverityLabels == nil {
 		c.SeverityLabels = ChDefaults.SeverityLabels
 	}
+	if c.Spool == nil {
+		c.Spool = ChDefaults.Spool
+	}
+	if c.SpoolSize == nil {
+		c.SpoolSize = ChDefaults.SpoolSize
+	}
+	if c.StackTrace == nil {
+		c.StackTrace = ChDefaults.StackTrace
+	}
+	if c.SyslogFormat == nil {
+		c.SyslogFormat = ChDefaults.SyslogFormat
+	}
+	if c.Tag == nil {
+		c.Tag = ChDefaults.Tag
+	}
+	if c.Timeout == nil {
+		c.Timeout = ChDefaults.Timeout
+	}
 	if c.Type == ChUndefined {
 		c.Type = ChDefaults.Type
 	}
+	if c.Name == nil {
+		name := c.Type.String()
+		c.Name = &name
+	}
+	if c.URL == nil {
+		c.URL = ChDefaults.URL
+	}
 	if c.Welcome == nil {
 		c.Welcome = ChDefaults.Welcome
 	}
+	if c.Width == nil {
+		c.Width = ChDefaults.Width
+	}
+	if c.Workers == nil {
+		c.Workers = ChDefaults.Workers
+	}
+	if c.Wrap == nil {
+		c.Wrap = ChDefaults.Wrap
+	}
 
 	// endregion: defaults
 	// region: create channel
@@ -246,22 +747,48 @@ func NewCh(cs ...ChConfig) (*Ch, error) {
 		Config:  c,
 		Encoder: c.Encoder,
 		Type:    c.Type,
+		hooks:   newHookChain(c.Hooks),
+		mu:      &sync.Mutex{},
+	}
+	threshold := int32(*c.Severity)
+	ch.severity = &threshold
+	ch.overrides = &atomic.Pointer[overrides]{}
+	if e := ch.SetOverrides(*c.Overrides); e != nil {
+		return nil, e
 	}
 
 	switch c.Type {
 	case ChDb:
-		return nil, ErrNotImplementedYet
-	case ChFile:
+		if e := ch.dbInit(); e != nil {
+			return nil, e
+		}
+	case ChFile, ChAudit:
 		if c.File == nil || c.File == "" {
 			return nil, ErrInvalidFile
 		}
+		if _, ok := c.File.(string); c.Type == ChAudit && (!ok || ch.rotating() || *c.FileFlags&os.O_APPEND == 0) {
+			return nil, ErrInvalidAudit
+		}
 
 		switch c.File.(type) {
 		case *os.File:
+			if ch.rotating() {
+				return nil, ErrInvalidRotation
+			}
 			ch.Inst = log.New(c.File.(io.Writer), *c.Prefix, *c.Flags)
 			ch.File = c.File.(*os.File)
 		case string:
-			f, err := os.OpenFile(c.File.(string), os.O_APPEND|os.O_CREATE|os.O_WRONLY, fs.FileMode(*c.FilePerm))
+			if ch.rotating() {
+				r, err := newRotator(&ch, c.File.(string))
+				if err != nil {
+					return nil, err
+				}
+				ch.Inst = log.New(r, *c.Prefix, *c.Flags)
+				ch.File = r.file // the one opened first, writes go through the rotator
+				ch.rotator = r
+				break
+			}
+			f, err := os.OpenFile(c.File.(string), *c.FileFlags, fs.FileMode(*c.FilePerm))
 			if err != nil {
 				return nil, err
 			}
@@ -274,16 +801,56 @@ func NewCh(cs ...ChConfig) (*Ch, error) {
 		default:
 			return nil, fmt.Errorf("%s: c.File=%s, (%T)", ErrInvalidFile, c.File, c.File)
 		}
+		if c.Type == ChAudit {
+			a, err := newAudit(&ch)
+			if err != nil {
+				ch.File.Close()
+				return nil, err
+			}
+			ch.audit = a
+		}
 	case ChSyslog:
 		inst, err := syslog.NewLogger(*c.Severity|*c.Facility, *c.Flags)
 		if err != nil {
 			return nil, err
 		}
 		ch.Inst = inst
+	case ChSyslogRemote:
+		r, err := newRemote(&ch)
+		if err != nil {
+			return nil, err
+		}
+		ch.remote = r
+	case ChSlog:
+		if c.Slog == nil {
+			return nil, ErrInvalidSlogHandler
+		}
+	case ChMemory:
+		ch.memory = newMemory(*c.MemorySize)
+	case ChHTTP:
+		sh, err := newShipper(&ch)
+		if err != nil {
+			return nil, err
+		}
+		ch.shipper = sh
+	case ChNet:
+		st, err := newStream(&ch)
+		if err != nil {
+			return nil, err
+		}
+		ch.Inst = log.New(st, *c.Prefix, *c.Flags)
+		ch.stream = st
 	default:
 		return nil, fmt.Errorf("%s: %v", ErrInvalidLoggerOrChannel, c.Type)
 	}
 
+	if *c.Queue > 0 {
+		ch.queue = newQueue(&ch, *c.Queue, *c.QueuePolicy, *c.Workers)
+	}
+	if ch.limiting() {
+		ch.limiter = newLimiter(&ch, *c.Dedup, *c.RateLimits)
+	}
+
 	// endregion: channel
 	// region: welcome and back
 
@@ -296,47 +863,101 @@ func NewCh(cs ...ChConfig) (*Ch, error) {
 
 }
 
-func (l *Logger) NewCh(cs ...ChConfig) (ch *Ch, e error) {
-	ch, e = NewCh(cs...)
-	if e != nil {
-		return nil, e
-	}
-	l.Ch = append(l.Ch, ch)
-	return
-}
-
 func (c *Ch) Close() (e error) {
-	if c.Type != ChFile {
+	if c.limiter != nil {
+		c.limiter.close() // pending repeat counts
+	}
+	if c.queue != nil {
+		c.queue.close() // drain before bye
+	}
+	if c.Type == ChSyslog {
 		return ErrNotImplementedYet
 	}
 	if c.Config.Bye != nil {
 		c.Out(*c.Config.Bye)
 	}
+	if c.Type == ChDb || c.Type == ChSlog {
+		return nil // the connection or handler belongs to the caller, it's not ours to close
+	}
+	if c.Type == ChMemory {
+		c.memory.close() // the entries are kept for a post mortem
+		return nil
+	}
+	if c.Type == ChSyslogRemote {
+		return c.remote.close()
+	}
+	if c.Type == ChNet {
+		return c.stream.close()
+	}
+	if c.Type == ChHTTP {
+		return c.shipper.close()
+	}
+	if c.Type == ChAudit {
+		return c.audit.close()
+	}
+	if c.rotator != nil {
+		return c.rotator.Close()
+	}
 	if e := c.File.Close(); e != nil {
 		return e
 	}
 	return nil
 }
 
+func (t ChType) String() string {
+	if name, ok := chTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("ChType(%d)", int(t))
+}
+
+func (c *Ch) Name() string {
+	if c.Config.Name == nil {
+		return c.Type.String()
+	}
+	return *c.Config.Name
+}
+
+// Entry returns the entry being encoded, it is only set during Out(), for the encoders
+
+func (c *Ch) Entry() *Entry {
+	return c.entry
+}
+
 // endregion: destinations and destructors
 // region: encoders
 
 var EncoderFlat Encoder = func(c *Ch, n ...interface{}) (s string, e error) {
 
+	// nothing to encode
+	if len(n) == 0 && len(c.Fields()) == 0 {
+		return
+	}
+
 	// prefix with severity label, if needed
-	if severity, ok := n[0].(syslog.Priority); ok {
-		labels := *c.Config.SeverityLabels
-		label := labels[severity]
-		s = label + s
-		_, n = n[0], n[1:]
+	if len(n) > 0 {
+		if severity, ok := n[0].(syslog.Priority); ok {
+			labels := *c.Config.SeverityLabels
+			label := labels[severity]
+			s = label + s
+			_, n = n[0], n[1:]
+		}
 	}
 
 	// encode
 	for _, v := range n {
 		s = fmt.Sprintf("%s%s%+v", s, *c.Config.Delimiter, v)
 	}
+	for _, f := range c.Fields() {
+		s = fmt.Sprintf("%s%s%s", s, *c.Config.Delimiter, f)
+	}
 	s = strings.Replace(s, *c.Config.Delimiter, "", 1)
 
+	// stack trace as an indented block below
+	if entry := c.Entry(); entry != nil && len(entry.Stack) > 0 {
+		s = fmt.Sprintf("%s\n%s", s, entry.Stack)
+	}
+
 	// done
 	return s, nil
 }
@@ -345,77 +966,262 @@ var EncoderFlat Encoder = func(c *Ch, n ...interface{}) (s string, e error) {
 // region: output
 
 func (c *Ch) Out(s ...interface{}) (e error) {
-	// set depth
-	depth := 2 + *c.Config.Depth
-	hood := Trace(depth)
-	for k, v := range hood {
-		if v.File != hood[0].File {
-			depth = depth + k - 1
-			break
+	entry, e := newEntry(c.fields, s...)
+	if entry == nil {
+		return e
+	}
+	if entry.Severity != nil && c.verbosity() < *entry.Severity && c.scope == nil {
+		return nil
+	}
+	entry.Caller, entry.Origin = caller(*c.Config.Depth)
+	if c.stacking(entry) {
+		entry.Stack = stack()
+	}
+	return c.dispatch(entry)
+}
+
+// newEntry takes the severity off the args, the caller is left to the one who knows how deep it is
+
+func newEntry(fields Fields, s ...interface{}) (*Entry, error) {
+	entry := Entry{Args: s, Fields: fields, Time: time.Now()}
+	if len(s) > 0 {
+		if severity, ok := s[0].(syslog.Priority); ok {
+			if severity > LOG_DEBUG {
+				return nil, ErrInvalidSeverity
+			}
+			entry.Severity = &severity
+			entry.Args = s[1:]
 		}
 	}
+	return &entry, nil
+}
+
+// stacking tells if the entry should carry the stack trace, which has to be collected before it leaves the caller's goroutine
+
+func (c *Ch) stacking(entry *Entry) bool {
+	return entry.Severity != nil && *entry.Severity <= *c.Config.StackTrace && *entry.Severity <= c.threshold(entry)
+}
 
-	// check severity
-	severity, severityOk := s[0].(syslog.Priority)
-	if severityOk {
-		if severity > LOG_DEBUG {
-			return ErrInvalidSeverity
+// dispatch filters by severity, entries below it are held back if there is a scope, and released before the next one that triggers it
+
+func (c *Ch) dispatch(entry *Entry) (e error) {
+	if entry.Severity != nil && c.threshold(entry) < *entry.Severity {
+		if c.scope != nil {
+			c.scope.hold(c, entry)
 		}
-		if *c.Config.Severity < severity {
-			return nil
+		return nil
+	}
+	if c.scope != nil && entry.Severity != nil && *entry.Severity <= *c.Config.ScopeTrigger {
+		for _, held := range c.scope.release(c) {
+			if err := c.deliver(held); err != nil {
+				e = err
+			}
 		}
 	}
+	if err := c.deliver(entry); err != nil {
+		e = err
+	}
+	return e
+}
+
+// deliver runs the hooks and the limiter of the channel, then sends the entries on
+
+func (c *Ch) deliver(entry *Entry) (e error) {
+	hooks := c.hooks.snapshot()
+	for _, entry := range runPre(hooks, entry) {
+		if c.limiter != nil && !c.limiter.allow(entry) {
+			continue
+		}
+		if err := c.send(entry); err != nil {
+			e = err
+		}
+	}
+	return e
+}
+
+// send writes the entry or hands it over to the queue
+
+func (c *Ch) send(entry *Entry) error {
+	if c.queue != nil && c.queue.push(entry) {
+		return nil
+	}
+	return c.emit(entry)
+}
+
+// emit is write with the post hooks, the queue workers end up here as well
+
+func (c *Ch) emit(entry *Entry) error {
+	e := c.write(entry)
+	runPost(c.hooks.snapshot(), entry, e)
+	return e
+}
+
+func (c *Ch) write(entry *Entry) (e error) {
+
+	// secrets are masked on a copy, hooks and the other channels still see the original
+	if *c.Config.Redact {
+		entry = redactEntry(entry)
+	}
+
+	// encoders get the entry on a shallow copy, so concurrent calls don't step on each other
+	view := *c
+	view.entry = entry
+	s := entry.Args
+	if entry.Severity != nil {
+		s = append([]interface{}{*entry.Severity}, s...)
+	}
+
+	// memory keeps the entry as it is, db has its own columns for severity and caller, slog its own record
+	if c.Type == ChMemory {
+		c.memory.add(entry)
+		return nil
+	}
+	if c.Type == ChDb {
+		return view.dbOut(s...)
+	}
+	if c.Type == ChSlog {
+		return view.slogOut()
+	}
 
 	// encode and out
-	o, e := Encoder(*c.Encoder)(c, s...)
+	o, e := Encoder(*c.Encoder)(&view, s...)
 	if e != nil {
-		c.Inst.Output(depth, e.Error())
+		if c.Inst == nil || c.Type == ChAudit {
+			return e // nothing goes around the chain
+		}
+		c.output(entry, e.Error())
 	}
 
 	switch c.Type {
-	case ChDb:
-		return ErrNotImplementedYet
-	case ChFile:
-		c.Inst.Output(depth, o)
+	case ChFile, ChNet:
+		return c.output(entry, o)
 	case ChSyslog:
-		if severityOk {
+		if entry.Severity != nil {
 			writer := c.Inst.Writer().(*syslog.Writer)
-			switch severity {
+			switch *entry.Severity {
 			case LOG_EMERG:
-				writer.Emerg(o)
+				return writer.Emerg(o)
 			case LOG_ALERT:
-				writer.Alert(o)
+				return writer.Alert(o)
 			case LOG_CRIT:
-				writer.Crit(o)
+				return writer.Crit(o)
 			case LOG_ERR:
-				writer.Err(o)
+				return writer.Err(o)
 			case LOG_WARNING:
-				writer.Warning(o)
+				return writer.Warning(o)
 			case LOG_NOTICE:
-				writer.Notice(o)
+				return writer.Notice(o)
 			case LOG_INFO:
-				writer.Info(o)
+				return writer.Info(o)
 			case LOG_DEBUG:
-				writer.Debug(o)
+				return writer.Debug(o)
 			default:
 				return ErrInvalidSeverity
 			}
-		} else {
-			c.Inst.Output(depth, o)
 		}
+		return c.output(entry, o)
+	case ChSyslogRemote:
+		severity := *c.Config.Severity
+		if entry.Severity != nil {
+			severity = *entry.Severity
+		}
+		return c.remote.write(severity, entry.Time, o)
+	case ChHTTP:
+		severity := *c.Config.Severity
+		if entry.Severity != nil {
+			severity = *entry.Severity
+		}
+		c.shipper.add(severity, entry.Time, o)
+		return nil
+	case ChAudit:
+		return c.audit.write(o)
 	default:
 		return fmt.Errorf("%s: %v", ErrInvalidLoggerOrChannel, c.Type)
 	}
-	return
+}
+
+// output does what log.Logger.Output() would, but with the caller and time of the entry instead of the call stack, which is gone by the time a worker gets here
+
+func (c *Ch) output(entry *Entry, s string) error {
+	flags := c.Inst.Flags()
+	prefix := c.Inst.Prefix()
+
+	var buf []byte
+	if flags&log.Lmsgprefix == 0 {
+		buf = append(buf, prefix...)
+	}
+	if flags&(log.Ldate|log.Ltime|log.Lmicroseconds) != 0 {
+		t := entry.Time
+		if flags&log.LUTC != 0 {
+			t = t.UTC()
+		}
+		if flags&log.Ldate != 0 {
+			buf = t.AppendFormat(buf, "2006/01/02 ")
+		}
+		if flags&(log.Ltime|log.Lmicroseconds) != 0 {
+			if flags&log.Lmicroseconds != 0 {
+				buf = t.AppendFormat(buf, "15:04:05.000000 ")
+			} else {
+				buf = t.AppendFormat(buf, "15:04:05 ")
+			}
+		}
+	}
+	if flags&(log.Lshortfile|log.Llongfile) != 0 {
+		file, line := entry.Caller.File, entry.Caller.Line
+		if file == "" {
+			file, line = "???", 0
+		}
+		if flags&log.Lshortfile != 0 {
+			file = filepath.Base(file)
+		}
+		buf = append(buf, file...)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, int64(line), 10)
+		buf = append(buf, ": "...)
+	}
+	if flags&log.Lmsgprefix != 0 {
+		buf = append(buf, prefix...)
+	}
+	buf = append(buf, s...)
+	if len(s) == 0 || s[len(s)-1] != '\n' {
+		buf = append(buf, '\n')
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, e := c.Inst.Writer().Write(buf)
+	return e
 }
 
 func (l *Logger) Out(s ...interface{}) *[]error {
+	entry, e := newEntry(l.fields, s...)
+	if entry == nil {
+		return &[]error{e}
+	}
+
+	// nothing to do if no channel takes it and no hook could change that
+	l.lazy()
+	hooks := l.hooks.snapshot()
+	if entry.Severity != nil && len(hooks) == 0 && l.verbosity() < *entry.Severity && l.scope == nil {
+		return nil
+	}
+	entry.Caller, entry.Origin = caller(0)
+	return l.out(entry, hooks)
+}
+
+// out runs the hooks of the logger around handing the entry to the channels
+
+func (l *Logger) out(entry *Entry, hooks []Hook) *[]error {
 	es := make([]error, 0)
-	for _, c := range l.Ch {
-		e := c.Out(s...)
-		if e != nil {
+	for _, entry := range runPre(hooks, entry) {
+		var first error
+		for _, e := range l.dispatch(entry) {
+			if first == nil {
+				first = e
+			}
 			es = append(es, e)
 		}
+		runPost(hooks, entry, first)
 	}
 	if len(es) == 0 {
 		return nil
@@ -423,6 +1229,32 @@ func (l *Logger) Out(s ...interface{}) *[]error {
 	return &es
 }
 
+// dispatch hands a copy of the entry to each channel, with the fields and caller depth of the channel
+
+func (l *Logger) dispatch(entry *Entry) (es []error) {
+	var trace Frames
+	for _, c := range l.Channels() {
+		e := entry.clone()
+		e.Fields = joinFields(e.Fields, c.fields)
+		if *c.Config.Depth != 0 {
+			e.Caller, e.Origin = caller(*c.Config.Depth)
+		}
+		if e.Stack == nil && c.stacking(e) {
+			if trace == nil {
+				trace = stack()
+			}
+			e.Stack = trace
+		}
+		if l.scope != nil {
+			c = c.scoped(l.scope)
+		}
+		if err := c.dispatch(e); err != nil {
+			es = append(es, err)
+		}
+	}
+	return
+}
+
 func Out(c interface{}, p syslog.Priority, s ...interface{}) *[]error {
 
 	// prepare return slice
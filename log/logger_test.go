@@ -0,0 +1,55 @@
+package log_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/SandorMiskey/TEx-kit/log"
+)
+
+func TestLoggerZeroValue(t *testing.T) {
+	var l log.Logger
+	if chs := l.Channels(); len(chs) != 0 {
+		t.Fatalf("%d channels", len(chs))
+	}
+	ch, e := l.NewCh("memory", log.ChConfig{Type: log.ChMemory})
+	if e != nil {
+		t.Fatal(e)
+	}
+	ch.Reset()
+
+	dropped := 0
+	l.AddHook(log.Hook{Pre: func(e *log.Entry) []*log.Entry { dropped++; return nil }, Filter: log.FilterMessage("drop")})
+	l.With("k", "v").Out(log.LOG_INFO, "kept")
+	l.Out(log.LOG_INFO, "drop me")
+	if n := len(ch.Entries(nil)); n != 1 || dropped != 1 {
+		t.Errorf("%d entries, %d dropped", n, dropped)
+	}
+
+	if l.Get("memory") != ch {
+		t.Error("Get")
+	}
+	if _, e := l.Replace("memory", log.ChConfig{Type: log.ChMemory}); e != nil {
+		t.Error(e)
+	}
+	if e := l.Remove("memory"); e != nil {
+		t.Error(e)
+	}
+	if e := l.Close(); e != nil {
+		t.Error(e)
+	}
+}
+
+func TestLoggerZeroValueConcurrent(t *testing.T) {
+	var l log.Logger
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Out(log.LOG_INFO, "nowhere")
+			l.Channels()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,151 @@
+// region: packages
+
+// Package logtest captures what the code under test logs, for assertions in unit tests
+
+package logtest
+
+import (
+	"fmt"
+	"log/syslog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SandorMiskey/TEx-kit/log"
+)
+
+// endregion: packages
+// region: types
+
+// Logger is a log.Logger with a single ChMemory channel, pass Logger.Logger wherever a *log.Logger is expected
+
+type Logger struct {
+	*log.Logger
+	Ch *log.Ch
+
+	t testing.TB
+}
+
+// endregion: types
+// region: constructor
+
+var size = 10000
+
+// New captures every severity, the entries are dumped through t.Log if the test fails, and the logger is closed when it's over
+
+func New(t testing.TB) *Logger {
+	t.Helper()
+
+	l := log.NewLogger()
+	ch, e := l.NewCh("logtest", log.ChConfig{Type: log.ChMemory, MemorySize: &size})
+	if e != nil {
+		t.Fatalf("logtest: %s", e)
+	}
+	ch.Reset() // no welcome
+
+	tl := Logger{Logger: l, Ch: ch, t: t}
+	t.Cleanup(func() {
+		t.Helper()
+		if t.Failed() {
+			tl.Dump()
+		}
+		l.Close()
+	})
+	return &tl
+}
+
+// endregion: constructor
+// region: entries
+
+func (l *Logger) Entries() []*log.Entry {
+	return l.Ch.Entries(nil)
+}
+
+func (l *Logger) Reset() {
+	l.Ch.Reset()
+}
+
+// Dump writes the captured entries to the test log
+
+func (l *Logger) Dump() {
+	l.t.Helper()
+	entries := l.Entries()
+	l.t.Logf("logtest: %d entries captured", len(entries))
+	for _, e := range entries {
+		l.t.Log(format(e))
+	}
+}
+
+func format(e *log.Entry) string {
+	s := fmt.Sprintf("%s:%d: ", filepath.Base(e.Caller.File), e.Caller.Line)
+	if e.Severity != nil {
+		s = fmt.Sprintf("%s[%s] ", s, log.SeverityNames[*e.Severity])
+	}
+	s = s + e.Message()
+	fields := append(log.Fields{}, e.Fields...)
+	for _, v := range e.Args {
+		switch v := v.(type) {
+		case log.Field:
+			fields = append(fields, v)
+		case log.Fields:
+			fields = append(fields, v...)
+		case map[string]interface{}:
+			fields = append(fields, log.NewFields(v)...)
+		}
+	}
+	for _, f := range fields {
+		s = fmt.Sprintf("%s %s", s, f)
+	}
+	return s
+}
+
+// endregion: entries
+// region: assertions
+
+// Logged tells if there is an entry at severity p containing substr in its message
+
+func (l *Logger) Logged(p syslog.Priority, substr string) bool {
+	return len(l.Ch.Entries(match(p, substr))) > 0
+}
+
+func (l *Logger) AssertLogged(p syslog.Priority, substr string) bool {
+	l.t.Helper()
+	if !l.Logged(p, substr) {
+		l.t.Errorf("logtest: no %s entry containing %q", log.SeverityNames[p], substr)
+		return false
+	}
+	return true
+}
+
+func (l *Logger) AssertNotLogged(p syslog.Priority, substr string) bool {
+	l.t.Helper()
+	if l.Logged(p, substr) {
+		l.t.Errorf("logtest: unexpected %s entry containing %q", log.SeverityNames[p], substr)
+		return false
+	}
+	return true
+}
+
+// AssertNoErrors fails if anything at LOG_ERR or above has been logged
+
+func (l *Logger) AssertNoErrors() bool {
+	l.t.Helper()
+	errs := l.Ch.Entries(log.FilterSeverity(log.LOG_ERR))
+	if len(errs) > 0 {
+		s := make([]string, 0, len(errs))
+		for _, e := range errs {
+			s = append(s, format(e))
+		}
+		l.t.Errorf("logtest: %d error(s) logged:\n%s", len(errs), strings.Join(s, "\n"))
+		return false
+	}
+	return true
+}
+
+func match(p syslog.Priority, substr string) log.Filter {
+	return func(e *log.Entry) bool {
+		return e.Severity != nil && *e.Severity == p && strings.Contains(e.Message(), substr)
+	}
+}
+
+// endregion: assertions
@@ -0,0 +1,116 @@
+package logtest_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/SandorMiskey/TEx-kit/log"
+	"github.com/SandorMiskey/TEx-kit/log/logtest"
+)
+
+// fakeTB records what the assertions report instead of failing the real test
+
+type fakeTB struct {
+	testing.TB // the methods not overridden below panic, which is fine as long as logtest doesn't call them
+
+	cleanups []func()
+	errors   []string
+	logs     []string
+	failed   bool
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.failed = true
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...interface{}) {
+	f.Errorf(format, args...)
+}
+
+func (f *fakeTB) Log(args ...interface{}) {
+	f.logs = append(f.logs, fmt.Sprint(args...))
+}
+
+func (f *fakeTB) Logf(format string, args ...interface{}) {
+	f.logs = append(f.logs, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func (f *fakeTB) Failed() bool {
+	return f.failed
+}
+
+func (f *fakeTB) cleanup() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestAssertLogged(t *testing.T) {
+	tb := &fakeTB{}
+	l := logtest.New(tb)
+	defer tb.cleanup()
+	l.Out(log.LOG_WARNING, "disk almost full")
+
+	if !l.AssertLogged(log.LOG_WARNING, "almost") || tb.failed {
+		t.Errorf("passing assertion failed: %v", tb.errors)
+	}
+	if l.AssertLogged(log.LOG_ERR, "almost") || !tb.failed {
+		t.Fatal("wrong severity passed")
+	}
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], `no err entry containing "almost"`) {
+		t.Errorf("errors %q", tb.errors)
+	}
+	if l.AssertLogged(log.LOG_WARNING, "empty") {
+		t.Error("wrong message passed")
+	}
+}
+
+func TestAssertNotLogged(t *testing.T) {
+	tb := &fakeTB{}
+	l := logtest.New(tb)
+	defer tb.cleanup()
+	l.Out(log.LOG_INFO, "user logged in")
+
+	if !l.AssertNotLogged(log.LOG_INFO, "logged out") || !l.AssertNotLogged(log.LOG_DEBUG, "logged in") || tb.failed {
+		t.Errorf("passing assertion failed: %v", tb.errors)
+	}
+	if l.AssertNotLogged(log.LOG_INFO, "logged in") || !tb.failed {
+		t.Fatal("logged entry passed")
+	}
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], `unexpected info entry containing "logged in"`) {
+		t.Errorf("errors %q", tb.errors)
+	}
+}
+
+func TestAssertNoErrors(t *testing.T) {
+	tb := &fakeTB{}
+	l := logtest.New(tb)
+	l.Out(log.LOG_WARNING, "just a warning")
+	if !l.AssertNoErrors() || tb.failed {
+		t.Errorf("warning failed the assertion: %v", tb.errors)
+	}
+
+	l.Out(log.LOG_ERR, "first", log.F("k", "v"))
+	l.Out(log.LOG_CRIT, "second")
+	if l.AssertNoErrors() || !tb.failed {
+		t.Fatal("errors passed")
+	}
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "2 error(s) logged") ||
+		!strings.Contains(tb.errors[0], "[err] first k=v") || !strings.Contains(tb.errors[0], "[crit] second") {
+		t.Errorf("errors %q", tb.errors)
+	}
+
+	// a failed test gets the entries dumped
+	tb.cleanup()
+	if len(tb.logs) != 4 || tb.logs[0] != "logtest: 3 entries captured" || !strings.Contains(tb.logs[1], "logtest_test.go:") {
+		t.Errorf("dump %q", tb.logs)
+	}
+}
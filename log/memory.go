@@ -0,0 +1,161 @@
+// region: packages
+
+package log
+
+import (
+	"sync"
+)
+
+// endregion: packages
+// region: types
+
+type memory struct {
+	mu      sync.RWMutex
+	entries []*Entry // ring, next is the oldest once it's full
+	full    bool
+	next    int
+
+	subs   map[int]chan *Entry
+	subsID int
+}
+
+// endregion: types
+// region: constructor
+
+func newMemory(size int) *memory {
+	if size < 1 {
+		size = 1
+	}
+	return &memory{
+		entries: make([]*Entry, size),
+		subs:    make(map[int]chan *Entry),
+	}
+}
+
+// endregion: constructor
+// region: ring
+
+func (m *memory) add(e *Entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[m.next] = e
+	m.next++
+	if m.next == len(m.entries) {
+		m.next = 0
+		m.full = true
+	}
+
+	// slow subscribers miss entries rather than hold up the channel
+	for _, sub := range m.subs {
+		select {
+		case sub <- e.clone():
+		default:
+		}
+	}
+}
+
+// list is the oldest first, the entries are copies, so the caller can do whatever it likes with them
+
+func (m *memory) list(f Filter, n int) []*Entry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ordered := m.entries[:m.next]
+	if m.full {
+		ordered = append(append([]*Entry{}, m.entries[m.next:]...), m.entries[:m.next]...)
+	}
+
+	list := make([]*Entry, 0)
+	for i := len(ordered) - 1; i >= 0 && (n < 0 || len(list) < n); i-- {
+		if f == nil || f(ordered[i]) {
+			list = append(list, ordered[i].clone())
+		}
+	}
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list
+}
+
+func (m *memory) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make([]*Entry, len(m.entries))
+	m.full = false
+	m.next = 0
+}
+
+func (m *memory) subscribe(buffer int) (<-chan *Entry, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subsID++
+	id := m.subsID
+	sub := make(chan *Entry, buffer)
+	m.subs[id] = sub
+
+	var once sync.Once
+	return sub, func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if _, ok := m.subs[id]; ok {
+				delete(m.subs, id)
+				close(sub)
+			}
+		})
+	}
+}
+
+func (m *memory) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, sub := range m.subs {
+		delete(m.subs, id)
+		close(sub)
+	}
+}
+
+// endregion: ring
+// region: ch
+
+// Entries returns the entries of a ChMemory channel matching f (all of them if f is nil), oldest first
+
+func (c *Ch) Entries(f Filter) []*Entry {
+	if c.memory == nil {
+		return nil
+	}
+	return c.memory.list(f, -1)
+}
+
+// Tail returns the last n entries of a ChMemory channel, oldest first
+
+func (c *Ch) Tail(n int) []*Entry {
+	if c.memory == nil || n < 1 {
+		return nil
+	}
+	return c.memory.list(nil, n)
+}
+
+// Reset empties the buffer of a ChMemory channel, the subscribers are kept
+
+func (c *Ch) Reset() {
+	if c.memory != nil {
+		c.memory.reset()
+	}
+}
+
+// Subscribe delivers the entries written to a ChMemory channel from now on, until cancel is called or the channel is closed.
+// Entries that don't fit into the buffer of a slow subscriber are skipped.
+
+func (c *Ch) Subscribe(buffer int) (entries <-chan *Entry, cancel func()) {
+	if c.memory == nil {
+		closed := make(chan *Entry)
+		close(closed)
+		return closed, func() {}
+	}
+	return c.memory.subscribe(buffer)
+}
+
+// endregion: ch
@@ -0,0 +1,169 @@
+// region: packages
+
+package log
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// endregion: packages
+// region: types
+
+// netConn is a connection that redials on demand, failed attempts are backed off exponentially instead of blocking the caller
+
+type netConn struct {
+	mu sync.Mutex
+
+	addr       string
+	backoff    time.Duration
+	backoffMax time.Duration
+	network    string
+	timeout    time.Duration
+	tls        *tls.Config
+
+	conn    net.Conn
+	next    time.Time
+	wait    time.Duration
+	connect func(conn net.Conn) error // called after each successful dial, before anything else is written
+}
+
+// endregion: types
+// region: constructor
+
+func newNetConn(c *Ch) (*netConn, error) {
+	n := netConn{
+		addr:       *c.Config.Addr,
+		backoff:    *c.Config.Backoff,
+		backoffMax: *c.Config.BackoffMax,
+		network:    *c.Config.Network,
+		timeout:    *c.Config.Timeout,
+		tls:        c.Config.TLS,
+	}
+	switch n.network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix", "unixgram", "tls":
+	default:
+		return nil, fmt.Errorf("%s: %s", ErrInvalidNetwork, n.network)
+	}
+	if n.addr == "" {
+		return nil, fmt.Errorf("%s: %s", ErrInvalidNetwork, "missing address")
+	}
+	return &n, nil
+}
+
+// endregion: constructor
+// region: dial
+
+func (n *netConn) stream() bool {
+	switch n.network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return false
+	}
+	return true
+}
+
+// caller holds n.mu
+
+func (n *netConn) dial() error {
+	if n.conn != nil {
+		return nil
+	}
+	if time.Now().Before(n.next) {
+		return ErrNotConnected
+	}
+
+	var conn net.Conn
+	var e error
+	dialer := net.Dialer{Timeout: n.timeout}
+	if n.network == "tls" {
+		conn, e = tls.DialWithDialer(&dialer, "tcp", n.addr, n.tls)
+	} else {
+		conn, e = dialer.Dial(n.network, n.addr)
+	}
+	if e == nil && n.connect != nil {
+		if e = n.connect(conn); e != nil {
+			conn.Close()
+		}
+	}
+	if e != nil {
+		n.fail()
+		return e
+	}
+
+	n.conn = conn
+	n.next = time.Time{}
+	n.wait = 0
+	return nil
+}
+
+// dialNow is dial() outside of Write(), the constructors call it for the first dial, which is not subject to backoff: the caller should know if the address is wrong
+
+func (n *netConn) dialNow() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.dial()
+}
+
+// caller holds n.mu
+
+func (n *netConn) fail() {
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn = nil
+	}
+	switch {
+	case n.wait == 0:
+		n.wait = n.backoff
+	case n.wait*2 > n.backoffMax:
+		n.wait = n.backoffMax
+	default:
+		n.wait = n.wait * 2
+	}
+	n.next = time.Now().Add(n.wait)
+}
+
+// endregion: dial
+// region: write and close
+
+// Write sends b in one go, a broken connection is redialed once right away, after that it's up to the backoff
+
+func (n *netConn) Write(b []byte) (int, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for retry := 0; ; retry++ {
+		if e := n.dial(); e != nil {
+			return 0, e
+		}
+		if n.timeout > 0 {
+			n.conn.SetWriteDeadline(time.Now().Add(n.timeout))
+		}
+		w, e := n.conn.Write(b)
+		if e == nil {
+			return w, nil
+		}
+		n.conn.Close()
+		n.conn = nil
+		if retry > 0 {
+			n.fail()
+			return w, e
+		}
+	}
+}
+
+func (n *netConn) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		return nil
+	}
+	e := n.conn.Close()
+	n.conn = nil
+	return e
+}
+
+// endregion: write and close
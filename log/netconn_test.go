@@ -0,0 +1,45 @@
+package log
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNetConnBackoff(t *testing.T) {
+	ln, e := net.Listen("tcp", "127.0.0.1:0")
+	if e != nil {
+		t.Fatal(e)
+	}
+	addr := ln.Addr().String()
+	ln.Close() // nobody listens there
+
+	n := netConn{addr: addr, backoff: time.Hour, backoffMax: 3 * time.Hour, network: "tcp"}
+	if _, e := n.Write([]byte("x")); e == nil || e == ErrNotConnected {
+		t.Fatalf("first write: %v, want the dial error", e)
+	}
+	if _, e := n.Write([]byte("x")); e != ErrNotConnected {
+		t.Fatalf("second write: %v, want %v while backing off", e, ErrNotConnected)
+	}
+	for _, want := range []time.Duration{2 * time.Hour, 3 * time.Hour, 3 * time.Hour} {
+		n.next = time.Time{}
+		n.Write([]byte("x"))
+		if n.wait != want {
+			t.Fatalf("wait %s, want %s", n.wait, want)
+		}
+	}
+
+	// a successful dial resets the backoff
+	if ln, e = net.Listen("tcp", addr); e != nil {
+		t.Skip(e)
+	}
+	defer ln.Close()
+	n.next = time.Time{}
+	if _, e := n.Write([]byte("x")); e != nil {
+		t.Fatal(e)
+	}
+	if n.wait != 0 || !n.next.IsZero() {
+		t.Errorf("wait %s, next %s after a successful dial", n.wait, n.next)
+	}
+	n.Close()
+}
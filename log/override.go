@@ -0,0 +1,208 @@
+// region: packages
+
+package log
+
+import (
+	"fmt"
+	"log/syslog"
+	"path"
+	"strings"
+)
+
+// endregion: packages
+// region: types
+
+// Override is a severity for the entries of matching callers (or the helpers they logged through), the pattern is a path.Match() one on the package
+// (like FilterPackage: "db", "main", "github.com/SandorMiskey/TEx-kit/*") or, if it ends with .go, on the file ("db.go", "db/*.go")
+
+type Override struct {
+	Pattern  string
+	Severity syslog.Priority
+}
+
+// Overrides are checked in order, the first matching one replaces the severity of the channel, in either direction
+
+type Overrides []Override
+
+// overrides is what the channel (and its copies made by With()) swap at runtime, verbosity is the most verbose of them
+
+type overrides struct {
+	list      Overrides
+	verbosity syslog.Priority
+}
+
+// endregion: types
+// region: parse
+
+// ParseOverrides takes the "db=debug,cfg=warning,main=info" form, severities as ParseSeverity() accepts them
+
+func ParseOverrides(s string) (Overrides, error) {
+	o := Overrides{}
+	for _, rule := range strings.Split(s, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		i := strings.LastIndex(rule, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("%s: %s", ErrInvalidOverride, rule)
+		}
+		pattern := strings.TrimSpace(rule[:i])
+		if _, e := path.Match(pattern, ""); e != nil {
+			return nil, fmt.Errorf("%s: %s", ErrInvalidOverride, rule)
+		}
+		p, e := ParseSeverity(rule[i+1:])
+		if e != nil {
+			return nil, fmt.Errorf("%s: %s", ErrInvalidOverride, rule)
+		}
+		o = append(o, Override{Pattern: pattern, Severity: p})
+	}
+	return o, nil
+}
+
+func (o Overrides) String() string {
+	rules := make([]string, 0, len(o))
+	for _, r := range o {
+		rules = append(rules, r.Pattern+"="+SeverityNames[r.Severity])
+	}
+	return strings.Join(rules, ",")
+}
+
+// endregion: parse
+// region: match
+
+func (r Override) match(f Frame) bool {
+	if !strings.HasSuffix(r.Pattern, ".go") {
+		return matchPackage(packageOf(f.Function), r.Pattern)
+	}
+
+	// as many trailing elements of the file as there are in the pattern
+	name := f.File
+	n := strings.Count(r.Pattern, "/") + 1
+	if elems := strings.Split(f.File, "/"); len(elems) > n {
+		name = strings.Join(elems[len(elems)-n:], "/")
+	}
+	ok, _ := path.Match(r.Pattern, name)
+	return ok
+}
+
+// match checks the rules in order, a rule matches if either of the frames does
+
+func (o Overrides) match(frames ...Frame) (syslog.Priority, bool) {
+	for _, r := range o {
+		for _, f := range frames {
+			if f.Function != "" && r.match(f) {
+				return r.Severity, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// endregion: match
+// region: ch
+
+func newOverrides(o Overrides) *overrides {
+	state := overrides{list: o, verbosity: LOG_EMERG}
+	for _, r := range o {
+		if r.Severity > state.verbosity {
+			state.verbosity = r.Severity
+		}
+	}
+	return &state
+}
+
+// threshold is the severity of the channel for the entry, by its caller or by the helper it logged through (eg. "db=debug" for db.Exec())
+
+func (c *Ch) threshold(entry *Entry) syslog.Priority {
+	if c.overrides != nil {
+		if state := c.overrides.Load(); len(state.list) > 0 {
+			if p, ok := state.list.match(entry.Caller, entry.Origin); ok {
+				return p
+			}
+		}
+	}
+	return c.Severity()
+}
+
+// verbosity is the least severe entry that might get through, whoever the caller is
+
+func (c *Ch) verbosity() syslog.Priority {
+	p := c.Severity()
+	if c.overrides != nil {
+		if v := c.overrides.Load().verbosity; v > p {
+			p = v
+		}
+	}
+	return p
+}
+
+// Overrides returns the current overrides of the channel, shared by the copies made by With()
+
+func (c *Ch) Overrides() Overrides {
+	if c.overrides == nil {
+		return nil
+	}
+	return append(Overrides{}, c.overrides.Load().list...)
+}
+
+// SetOverrides replaces the overrides of the channel, nil or empty clears them
+
+func (c *Ch) SetOverrides(o Overrides) error {
+	for _, r := range o {
+		if r.Severity < LOG_EMERG || r.Severity > LOG_DEBUG {
+			return fmt.Errorf("%s: %s", ErrInvalidSeverity, r.Pattern)
+		}
+		if _, e := path.Match(r.Pattern, ""); e != nil {
+			return fmt.Errorf("%s: %s", ErrInvalidOverride, r.Pattern)
+		}
+	}
+	if c.overrides == nil {
+		return ErrInvalidLoggerOrChannel
+	}
+	c.overrides.Store(newOverrides(append(Overrides{}, o...)))
+	return nil
+}
+
+// ResetOverrides goes back to ChConfig.Overrides
+
+func (c *Ch) ResetOverrides() error {
+	return c.SetOverrides(*c.Config.Overrides)
+}
+
+// endregion: ch
+// region: logger
+
+// verbosity is the most verbose of the channels, overrides included
+
+func (l *Logger) verbosity() syslog.Priority {
+	p := LOG_EMERG
+	for _, c := range l.Channels() {
+		if v := c.verbosity(); v > p {
+			p = v
+		}
+	}
+	return p
+}
+
+// SetOverrides sets the same overrides on all the channels
+
+func (l *Logger) SetOverrides(o Overrides) (e error) {
+	for _, c := range l.Channels() {
+		if err := c.SetOverrides(o); err != nil {
+			e = err
+		}
+	}
+	return e
+}
+
+func (l *Logger) ResetOverrides() (e error) {
+	for _, c := range l.Channels() {
+		if err := c.ResetOverrides(); err != nil {
+			e = err
+		}
+	}
+	return e
+}
+
+// endregion: logger
@@ -0,0 +1,84 @@
+package log_test
+
+import (
+	"testing"
+
+	"github.com/SandorMiskey/TEx-kit/log"
+	"github.com/SandorMiskey/TEx-kit/log/testdata/helper"
+)
+
+func newMemory(t testing.TB, c log.ChConfig) *log.Ch {
+	t.Helper()
+	c.Type = log.ChMemory
+	ch, e := log.NewCh(c)
+	if e != nil {
+		t.Fatal(e)
+	}
+	ch.Reset()
+	t.Cleanup(func() { ch.Close() })
+	return ch
+}
+
+func TestOverrideHelper(t *testing.T) {
+	info := log.LOG_INFO
+	ch := newMemory(t, log.ChConfig{Severity: &info})
+
+	for _, tc := range []struct {
+		overrides string
+		kept      bool
+	}{
+		{"", false},
+		{"helper=debug", true},
+		{"helper.go=debug", true},
+		{"log_test=debug", true},
+		{"helper=info,log_test=debug", false}, // first match wins
+		{"other=debug", false},
+	} {
+		o, e := log.ParseOverrides(tc.overrides)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if e := ch.SetOverrides(o); e != nil {
+			t.Fatal(e)
+		}
+		ch.Reset()
+		helper.Out(ch, log.LOG_DEBUG, "via helper")
+
+		entries := ch.Entries(nil)
+		if got := len(entries) == 1; got != tc.kept {
+			t.Errorf("%q: kept %v, want %v", tc.overrides, got, tc.kept)
+			continue
+		}
+		if !tc.kept {
+			continue
+		}
+		if fn := entries[0].Caller.Function; fn != "github.com/SandorMiskey/TEx-kit/log_test.TestOverrideHelper" {
+			t.Errorf("%q: caller %s", tc.overrides, fn)
+		}
+		if fn := entries[0].Origin.Function; fn != "github.com/SandorMiskey/TEx-kit/log/testdata/helper.Out" {
+			t.Errorf("%q: origin %s", tc.overrides, fn)
+		}
+	}
+}
+
+func TestOverrideDirect(t *testing.T) {
+	info := log.LOG_INFO
+	ch := newMemory(t, log.ChConfig{Severity: &info})
+	o, _ := log.ParseOverrides("helper=debug")
+	ch.SetOverrides(o)
+
+	ch.Out(log.LOG_DEBUG, "direct")
+	if n := len(ch.Entries(nil)); n != 0 {
+		t.Fatalf("%d entries, the override of the helper applies to the direct call", n)
+	}
+	o, _ = log.ParseOverrides("log_test=debug")
+	ch.SetOverrides(o)
+	ch.Out(log.LOG_DEBUG, "direct")
+	entries := ch.Entries(nil)
+	if len(entries) != 1 {
+		t.Fatalf("%d entries", len(entries))
+	}
+	if entries[0].Origin != entries[0].Caller {
+		t.Errorf("origin %+v, caller %+v", entries[0].Origin, entries[0].Caller)
+	}
+}
@@ -0,0 +1,65 @@
+package log_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SandorMiskey/TEx-kit/log"
+)
+
+type redactConfig struct {
+	DSN    string
+	Passwd string `log:"secret"`
+	Port   int
+	Inner  *redactConfig
+}
+
+func TestRedact(t *testing.T) {
+	cfg := redactConfig{DSN: "user:hunter2@tcp(db)/app", Passwd: "hunter2", Port: 3306, Inner: &redactConfig{Passwd: "x"}}
+	for _, tc := range []struct {
+		in   interface{}
+		want interface{}
+	}{
+		{42, 42},
+		{3.14, 3.14},
+		{true, true},
+		{time.Second, time.Second},
+		{"password=hunter2", "password=" + log.Mask},
+		{"postgres://bob:hunter2@db/app", "postgres://bob:" + log.Mask + "@db/app"},
+		{log.F("token", "abc"), log.F("token", log.Mask)},
+	} {
+		if got := log.Redact(tc.in); got != tc.want {
+			t.Errorf("Redact(%v) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+
+	if e := log.Redact(errors.New("dial user:hunter2@tcp(db)/app")).(error); e.Error() != "dial user:"+log.Mask+"@tcp(db)/app" {
+		t.Errorf("error: %s", e)
+	}
+
+	r := log.Redact(&cfg).(*redactConfig)
+	if r.Passwd != log.Mask || r.DSN != "user:"+log.Mask+"@tcp(db)/app" || r.Port != 3306 || r.Inner.Passwd != log.Mask {
+		t.Errorf("struct: %+v, inner %+v", r, r.Inner)
+	}
+	if cfg.Passwd != "hunter2" || cfg.Inner.Passwd != "x" {
+		t.Error("the original has been changed")
+	}
+}
+
+func BenchmarkRedactScalars(b *testing.B) {
+	size := 1
+	ch := newMemory(b, log.ChConfig{MemorySize: &size})
+	for i := 0; i < b.N; i++ {
+		ch.Out(log.LOG_INFO, "request done", 200, 1.5, true, time.Millisecond)
+	}
+}
+
+func BenchmarkRedactStruct(b *testing.B) {
+	size := 1
+	ch := newMemory(b, log.ChConfig{MemorySize: &size})
+	cfg := redactConfig{DSN: "user:hunter2@tcp(db)/app", Passwd: "hunter2"}
+	for i := 0; i < b.N; i++ {
+		ch.Out(log.LOG_INFO, "connecting", cfg)
+	}
+}
@@ -0,0 +1,104 @@
+// region: packages
+
+package log
+
+import (
+	"fmt"
+	"log/syslog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// endregion: packages
+// region: types
+
+type SyslogFormat int
+
+type remote struct {
+	conn     *netConn
+	facility syslog.Priority
+	format   SyslogFormat
+	hostname string
+	pid      int
+	tag      string
+}
+
+// endregion: types
+// region: constants
+
+const (
+	RFC5424 SyslogFormat = iota
+	RFC3164
+)
+
+// endregion: constants
+// region: constructor
+
+func newRemote(c *Ch) (*remote, error) {
+	conn, e := newNetConn(c)
+	if e != nil {
+		return nil, e
+	}
+
+	r := remote{
+		conn:     conn,
+		facility: *c.Config.Facility,
+		format:   *c.Config.SyslogFormat,
+		hostname: *c.Config.Hostname,
+		pid:      os.Getpid(),
+		tag:      *c.Config.Tag,
+	}
+	if r.hostname == "" {
+		r.hostname, _ = os.Hostname()
+	}
+	if r.hostname == "" {
+		r.hostname = "-"
+	}
+	if r.tag == "" {
+		r.tag = filepath.Base(os.Args[0])
+	}
+
+	if e := conn.dialNow(); e != nil {
+		return nil, e
+	}
+	return &r, nil
+}
+
+// endregion: constructor
+// region: format
+
+func (r *remote) format5424(p syslog.Priority, t time.Time, msg string) string {
+	return fmt.Sprintf("<%d>1 %s %s %s %d - - %s", r.facility|p, t.Format("2006-01-02T15:04:05.000000Z07:00"), r.hostname, r.tag, r.pid, msg)
+}
+
+func (r *remote) format3164(p syslog.Priority, t time.Time, msg string) string {
+	return fmt.Sprintf("<%d>%s %s %s[%d]: %s", r.facility|p, t.Format(time.Stamp), r.hostname, r.tag, r.pid, msg)
+}
+
+// endregion: format
+// region: output
+
+func (r *remote) write(p syslog.Priority, t time.Time, msg string) error {
+	var s string
+	if r.format == RFC3164 {
+		s = r.format3164(p, t, strings.TrimSuffix(msg, "\n"))
+	} else {
+		s = r.format5424(p, t, strings.TrimSuffix(msg, "\n"))
+	}
+
+	// octet-counting (RFC 6587) on streams, one message per datagram otherwise
+	if r.conn.stream() {
+		s = strconv.Itoa(len(s)) + " " + s
+	}
+	_, e := r.conn.Write([]byte(s))
+	return e
+}
+
+func (r *remote) close() error {
+	return r.conn.Close()
+}
+
+// endregion: output
@@ -0,0 +1,178 @@
+package log_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/syslog"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SandorMiskey/TEx-kit/log"
+)
+
+func newRemote(t *testing.T, network string, addr string, format log.SyslogFormat) *log.Ch {
+	t.Helper()
+	facility := syslog.LOG_LOCAL0
+	hostname := "host"
+	tag := "app"
+	backoff := 10 * time.Millisecond
+	ch, e := log.NewCh(log.ChConfig{
+		Type:         log.ChSyslogRemote,
+		Addr:         &addr,
+		Backoff:      &backoff,
+		BackoffMax:   &backoff,
+		Facility:     &facility,
+		Hostname:     &hostname,
+		Network:      &network,
+		SyslogFormat: &format,
+		Tag:          &tag,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	t.Cleanup(func() { ch.Close() })
+	return ch
+}
+
+// readFrame reads one octet counted message (RFC 6587)
+
+func readFrame(r *bufio.Reader) (string, error) {
+	n, e := r.ReadString(' ')
+	if e != nil {
+		return "", e
+	}
+	size, e := strconv.Atoi(strings.TrimSuffix(n, " "))
+	if e != nil {
+		return "", fmt.Errorf("bad octet count %q", n)
+	}
+	b := make([]byte, size)
+	_, e = io.ReadFull(r, b)
+	return string(b), e
+}
+
+// receive returns the first message containing marker, the welcome and whatever else comes before it is skipped
+
+func receive(t *testing.T, next func() (string, error), marker string) string {
+	t.Helper()
+	for {
+		msg, e := next()
+		if e != nil {
+			t.Fatalf("waiting for %q: %s", marker, e)
+		}
+		if strings.Contains(msg, marker) {
+			return msg
+		}
+	}
+}
+
+func TestRemoteUDP5424(t *testing.T) {
+	pc, e := net.ListenPacket("udp", "127.0.0.1:0")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer pc.Close()
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	ch := newRemote(t, "udp", pc.LocalAddr().String(), log.RFC5424)
+	if e := ch.Out(log.LOG_ERR, "hello udp"); e != nil {
+		t.Fatal(e)
+	}
+
+	buf := make([]byte, 64<<10)
+	msg := receive(t, func() (string, error) {
+		n, _, e := pc.ReadFrom(buf)
+		return string(buf[:n]), e
+	}, "hello udp")
+
+	// one message per datagram, no octet count
+	re := regexp.MustCompile(`^<131>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}(Z|[+-]\d\d:\d\d) host app ` + strconv.Itoa(os.Getpid()) + ` - - .*hello udp$`)
+	if !re.MatchString(msg) {
+		t.Errorf("not RFC 5424: %q", msg)
+	}
+}
+
+func TestRemoteTCP3164(t *testing.T) {
+	ln, e := net.Listen("tcp", "127.0.0.1:0")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer ln.Close()
+
+	ch := newRemote(t, "tcp", ln.Addr().String(), log.RFC3164)
+	conn, e := ln.Accept()
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	ch.Out(log.LOG_INFO, "hello tcp\n")
+	ch.Out(log.LOG_INFO, "second line")
+
+	r := bufio.NewReader(conn)
+	next := func() (string, error) { return readFrame(r) }
+	msg := receive(t, next, "hello tcp")
+	re := regexp.MustCompile(`^<134>[A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d host app\[` + strconv.Itoa(os.Getpid()) + `\]: .*hello tcp$`)
+	if !re.MatchString(msg) {
+		t.Errorf("not RFC 3164: %q", msg)
+	}
+
+	// the count keeps the messages apart, not the newlines
+	if msg := receive(t, next, "second line"); !strings.HasSuffix(msg, "second line") {
+		t.Errorf("second message: %q", msg)
+	}
+}
+
+func TestRemoteRedial(t *testing.T) {
+	ln, e := net.Listen("tcp", "127.0.0.1:0")
+	if e != nil {
+		t.Fatal(e)
+	}
+	addr := ln.Addr().String()
+	ch := newRemote(t, "tcp", addr, log.RFC5424)
+
+	conn, e := ln.Accept()
+	if e != nil {
+		t.Fatal(e)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	ch.Out(log.LOG_INFO, "before restart")
+	r := bufio.NewReader(conn)
+	receive(t, func() (string, error) { return readFrame(r) }, "before restart")
+
+	// restart
+	conn.Close()
+	ln.Close()
+	if ln, e = net.Listen("tcp", addr); e != nil {
+		t.Fatal(e)
+	}
+	defer ln.Close()
+
+	// the first writes may go to the dead connection or hit the backoff, keep at it until the new listener gets something
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, e := ln.Accept(); e == nil {
+			accepted <- conn
+		}
+	}()
+	deadline := time.After(5 * time.Second)
+	for conn = nil; conn == nil; {
+		ch.Out(log.LOG_INFO, "after restart")
+		select {
+		case conn = <-accepted:
+		case <-deadline:
+			t.Fatal("no redial")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r = bufio.NewReader(conn)
+	receive(t, func() (string, error) { return readFrame(r) }, "after restart")
+}
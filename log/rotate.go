@@ -0,0 +1,355 @@
+// region: packages
+
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// endregion: packages
+// region: types
+
+type Rotation int
+
+type rotator struct {
+	mu      sync.Mutex
+	cleanup sync.Mutex
+
+	compress bool
+	every    Rotation
+	flags    int
+	keep     int
+	maxAge   time.Duration
+	maxSize  int64
+	perm     fs.FileMode
+	utc      bool
+
+	bye     func() []byte
+	welcome func() []byte
+
+	file   *os.File
+	path   string
+	period time.Time
+	size   int64
+}
+
+// endregion: types
+// region: constants
+
+const (
+	RotateNever Rotation = iota
+	RotateHourly
+	RotateDaily
+)
+
+const rotateTimeFormat = "2006-01-02T15-04-05.000"
+
+// endregion: constants
+// region: constructor
+
+func (c *Ch) rotating() bool {
+	return *c.Config.RotateSize > 0 || *c.Config.RotateEvery != RotateNever
+}
+
+func newRotator(c *Ch, path string) (*rotator, error) {
+	r := rotator{
+		compress: *c.Config.RotateCompress,
+		every:    *c.Config.RotateEvery,
+		flags:    *c.Config.FileFlags,
+		keep:     *c.Config.RotateKeep,
+		maxAge:   *c.Config.RotateMaxAge,
+		maxSize:  *c.Config.RotateSize,
+		perm:     fs.FileMode(*c.Config.FilePerm),
+		utc:      *c.Config.Flags&log.LUTC != 0,
+		path:     path,
+	}
+
+	// markers are written straight to the file being closed or opened, going through Out() would deadlock on log.Logger
+	marker := func(msg *string) func() []byte {
+		return func() []byte {
+			if msg == nil {
+				return nil
+			}
+			s, e := Encoder(*c.Encoder)(c, *msg)
+			if e != nil {
+				s = e.Error()
+			}
+			var buf bytes.Buffer
+			log.New(&buf, *c.Config.Prefix, *c.Config.Flags&^(log.Lshortfile|log.Llongfile)).Output(0, s)
+			return buf.Bytes()
+		}
+	}
+	r.bye = marker(c.Config.Bye)
+	r.welcome = marker(c.Config.Welcome)
+
+	f, e := os.OpenFile(path, r.flags, r.perm)
+	if e != nil {
+		return nil, e
+	}
+	info, e := f.Stat()
+	if e != nil {
+		f.Close()
+		return nil, e
+	}
+	r.file = f
+	r.size = info.Size()
+	r.period = r.truncate(info.ModTime())
+	if r.size == 0 {
+		r.period = r.truncate(r.now())
+	}
+	return &r, nil
+}
+
+// endregion: constructor
+// region: write and close
+
+func (r *rotator) Write(p []byte) (n int, e error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.due(len(p)) {
+		// if rotation fails, we stay with the current file rather than lose the entry
+		_ = r.rotate()
+	}
+	n, e = r.file.Write(p)
+	r.size += int64(n)
+	return
+}
+
+func (r *rotator) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return os.ErrClosed
+	}
+	e := r.file.Close()
+	r.file = nil
+	return e
+}
+
+// endregion: write and close
+// region: rotate
+
+func (r *rotator) now() time.Time {
+	if r.utc {
+		return time.Now().UTC()
+	}
+	return time.Now()
+}
+
+func (r *rotator) truncate(t time.Time) time.Time {
+	if r.utc {
+		t = t.UTC()
+	}
+	switch r.every {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+func (r *rotator) due(n int) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.maxSize > 0 && r.size+int64(n) > r.maxSize {
+		return true
+	}
+	if r.every != RotateNever && !r.truncate(r.now()).Equal(r.period) {
+		return true
+	}
+	return false
+}
+
+// caller holds r.mu
+
+func (r *rotator) rotate() error {
+	now := r.now()
+	rotated := r.rotatedName(now)
+
+	// the old file stays open until the new one is in place
+	if e := os.Rename(r.path, rotated); e != nil {
+		return e
+	}
+	f, e := os.OpenFile(r.path, r.flags, r.perm)
+	if e != nil {
+		os.Rename(rotated, r.path)
+		return e
+	}
+
+	r.file.Write(r.bye())
+	r.file.Close()
+
+	r.file = f
+	r.period = r.truncate(now)
+	n, _ := r.file.Write(r.welcome())
+	r.size = int64(n)
+
+	go r.clean()
+	return nil
+}
+
+// rotatedName is the path plus the timestamp, and a counter if there has been a rotation in the same millisecond already,
+// since Rename() would replace that file
+
+func (r *rotator) rotatedName(t time.Time) string {
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(r.path, ext) + "-" + t.Format(rotateTimeFormat)
+	name := base + ext
+	for n := 1; ; n++ {
+		_, e := os.Lstat(name)
+		_, egz := os.Lstat(name + ".gz")
+		if os.IsNotExist(e) && os.IsNotExist(egz) {
+			return name
+		}
+		name = base + "-" + strconv.Itoa(n) + ext
+	}
+}
+
+// rotatedStamp parses the name rotatedName() gave, without the directory, the prefix and the extension
+
+func rotatedStamp(stamp string) (t time.Time, n int, ok bool) {
+	if len(stamp) > len(rotateTimeFormat) {
+		counter := stamp[len(rotateTimeFormat):]
+		if counter[0] != '-' {
+			return t, 0, false
+		}
+		var e error
+		if n, e = strconv.Atoi(counter[1:]); e != nil || n < 1 || counter[1] == '+' {
+			return t, 0, false
+		}
+		stamp = stamp[:len(rotateTimeFormat)]
+	}
+	t, e := time.Parse(rotateTimeFormat, stamp)
+	return t, n, e == nil
+}
+
+// endregion: rotate
+// region: cleanup
+
+func (r *rotator) clean() {
+	r.cleanup.Lock()
+	defer r.cleanup.Unlock()
+
+	rotated, compressed := r.rotated()
+
+	// compress
+	if r.compress {
+		for _, name := range rotated {
+			if e := gzipFile(name); e == nil {
+				compressed = append(compressed, name+".gz")
+			}
+		}
+		rotated = nil
+	}
+
+	// by the timestamp and the counter in the name, newest first
+	files := append(rotated, compressed...)
+	sort.Slice(files, func(i, j int) bool {
+		ti, ni := r.stampOf(files[i])
+		tj, nj := r.stampOf(files[j])
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return ni > nj
+	})
+
+	for k, name := range files {
+		if r.keep > 0 && k >= r.keep {
+			os.Remove(name)
+			continue
+		}
+		if r.maxAge > 0 {
+			if info, e := os.Stat(name); e == nil && time.Since(info.ModTime()) > r.maxAge {
+				os.Remove(name)
+			}
+		}
+	}
+}
+
+// rotated lists the files rotate() made of this one, by the timestamp in their name, app-error.log is not app.log rotated
+
+func (r *rotator) rotated() (rotated []string, compressed []string) {
+	dir := filepath.Dir(r.path)
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext) + "-"
+	entries, e := os.ReadDir(dir)
+	if e != nil {
+		return nil, nil
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz")
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		if _, _, ok := rotatedStamp(strings.TrimSuffix(stamp, ext)); !ok {
+			continue
+		}
+		if strings.HasSuffix(name, ".gz") {
+			compressed = append(compressed, filepath.Join(dir, name))
+		} else {
+			rotated = append(rotated, filepath.Join(dir, name))
+		}
+	}
+	return rotated, compressed
+}
+
+func (r *rotator) stampOf(path string) (time.Time, int) {
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext) + "-"
+	stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), prefix), ".gz"), ext)
+	t, n, _ := rotatedStamp(stamp)
+	return t, n
+}
+
+func gzipFile(name string) error {
+	src, e := os.Open(name)
+	if e != nil {
+		return e
+	}
+	defer src.Close()
+
+	info, e := src.Stat()
+	if e != nil {
+		return e
+	}
+	dst, e := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if e != nil {
+		return e
+	}
+	zw := gzip.NewWriter(dst)
+	if _, e = io.Copy(zw, src); e == nil {
+		e = zw.Close()
+	}
+	if ce := dst.Close(); e == nil {
+		e = ce
+	}
+	if e != nil {
+		os.Remove(name + ".gz")
+		return e
+	}
+	return os.Remove(name)
+}
+
+// endregion: cleanup
@@ -0,0 +1,131 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRotatorCleanLeavesOthersAlone(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"app.log",
+		"app-error.log",
+		"app-error.log.gz",
+		"app-2024-01-01.log",
+		"app-2024-01-01T00-00-00.000.log",
+		"app-2024-01-02T00-00-00.000.log",
+		"app-2024-01-03T00-00-00.000.log",
+		"app-2024-01-03T00-00-00.000-1.log", // rotated within the same millisecond, newer
+		"app-2024-01-04T00-00-00.000.log.gz",
+		"app-2024-01-05T00-00-00.000.txt",
+	} {
+		if e := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	r := rotator{compress: true, keep: 2, path: filepath.Join(dir, "app.log")}
+	r.clean()
+
+	entries, _ := os.ReadDir(dir)
+	got := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	want := []string{
+		"app-2024-01-01.log",
+		"app-2024-01-03T00-00-00.000-1.log.gz",
+		"app-2024-01-04T00-00-00.000.log.gz",
+		"app-2024-01-05T00-00-00.000.txt",
+		"app-error.log",
+		"app-error.log.gz",
+		"app.log",
+	}
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "app-error.log")); string(b) != "app-error.log" {
+		t.Errorf("app-error.log has been touched: %q", b)
+	}
+}
+
+func TestRotateConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	size := int64(200)
+	ch, e := NewCh(ChConfig{File: path, RotateSize: &size})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	const writers, entries = 8, 500
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < entries; i++ {
+				ch.Out(LOG_INFO, fmt.Sprintf("entry w%d-%d", w, i))
+			}
+		}(w)
+	}
+	wg.Wait()
+	if e := ch.Close(); e != nil {
+		t.Fatal(e)
+	}
+
+	// every entry is in one of the files, exactly once
+	seen := make(map[string]int)
+	files, _ := os.ReadDir(dir)
+	for _, f := range files {
+		b, e := os.ReadFile(filepath.Join(dir, f.Name()))
+		if e != nil {
+			t.Fatal(e)
+		}
+		for _, line := range strings.Split(string(b), "\n") {
+			if i := strings.Index(line, "entry w"); i >= 0 {
+				seen[line[i:]]++
+			}
+		}
+	}
+	if len(seen) != writers*entries {
+		t.Errorf("%d of %d entries in %d files", len(seen), writers*entries, len(files))
+	}
+	for entry, n := range seen {
+		if n != 1 {
+			t.Errorf("%q written %d times", entry, n)
+		}
+	}
+}
+
+func TestRotatedStamp(t *testing.T) {
+	for _, tc := range []struct {
+		stamp string
+		n     int
+		ok    bool
+	}{
+		{"2024-01-01T00-00-00.000", 0, true},
+		{"2024-01-01T00-00-00.000-1", 1, true},
+		{"2024-01-01T00-00-00.000-12", 12, true},
+		{"2024-01-01T00-00-00.000-", 0, false},
+		{"2024-01-01T00-00-00.000-0", 0, false},
+		{"2024-01-01T00-00-00.000-+1", 0, false},
+		{"2024-01-01T00-00-00.000.1", 0, false},
+		{"error", 0, false},
+	} {
+		if _, n, ok := rotatedStamp(tc.stamp); n != tc.n || ok != tc.ok {
+			t.Errorf("%s: %d, %v", tc.stamp, n, ok)
+		}
+	}
+}
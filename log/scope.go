@@ -0,0 +1,122 @@
+// region: packages
+
+package log
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// endregion: packages
+// region: types
+
+// scope holds back the entries below the severity of the channels ("fingers crossed"), per channel, oldest first
+
+type scope struct {
+	mu   sync.Mutex
+	held map[string][]*Entry
+}
+
+type contextKey struct{}
+
+// endregion: types
+// region: scope
+
+func newScope() *scope {
+	return &scope{held: make(map[string][]*Entry)}
+}
+
+func (s *scope) hold(c *Ch, entry *Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held := s.held[c.Name()]
+	if size := *c.Config.ScopeSize; size > 0 && len(held) >= size {
+		held = held[len(held)-size+1:]
+	}
+	s.held[c.Name()] = append(held, entry)
+}
+
+func (s *scope) release(c *Ch) []*Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held := s.held[c.Name()]
+	delete(s.held, c.Name())
+	return held
+}
+
+func (s *scope) discard() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.held = make(map[string][]*Entry)
+}
+
+// scoped is a copy of the channel writing through s, the underlying output is shared
+
+func (c *Ch) scoped(s *scope) *Ch {
+	view := *c
+	view.scope = s
+	return &view
+}
+
+// Scope returns a copy of the channel, which holds back the entries below its severity instead of dropping them.
+// They are written, in order, right before the next entry at or above ChConfig.ScopeTrigger (LOG_ERR by default),
+// or thrown away by Discard() if nothing went wrong. Meant to be one per request or unit of work, not to be shared.
+
+func (c *Ch) Scope() *Ch {
+	return c.scoped(newScope())
+}
+
+// Scope returns a logger sharing the channels of l, each of them holding back entries within the scope like Ch.Scope()
+
+func (l *Logger) Scope() *Logger {
+	l.lazy()
+	return &Logger{
+		chs:    l.chs,
+		fields: l.fields,
+		hooks:  l.hooks,
+		scope:  newScope(),
+	}
+}
+
+// Discard throws away what the scope held back, usually deferred at the end of a successful request, no-op without a scope
+
+func (c *Ch) Discard() {
+	if c.scope != nil {
+		c.scope.discard()
+	}
+}
+
+func (l *Logger) Discard() {
+	if l.scope != nil {
+		l.scope.discard()
+	}
+}
+
+// endregion: scope
+// region: context
+
+// NewContext returns a copy of ctx carrying l, eg. a scope for the request
+
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, nil if there is none
+
+func FromContext(ctx context.Context) *Logger {
+	l, _ := ctx.Value(contextKey{}).(*Logger)
+	return l
+}
+
+// Middleware gives each request a scope of l in its context, discarded when the handler returns
+
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := l.Scope()
+		defer scope.Discard()
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), scope)))
+	})
+}
+
+// endregion: context
@@ -0,0 +1,111 @@
+// region: packages
+
+package log
+
+import (
+	"fmt"
+	"log/syslog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// endregion: packages
+// region: parse
+
+var severityAliases = map[string]syslog.Priority{
+	"emergency":     LOG_EMERG,
+	"critical":      LOG_CRIT,
+	"error":         LOG_ERR,
+	"warn":          LOG_WARNING,
+	"informational": LOG_INFO,
+}
+
+// ParseSeverity accepts the names in SeverityNames (and a few aliases like "warn" or "error") in any case, or the number itself
+
+func ParseSeverity(s string) (syslog.Priority, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if n, e := strconv.Atoi(s); e == nil {
+		p := syslog.Priority(n)
+		if p < LOG_EMERG || p > LOG_DEBUG {
+			return 0, fmt.Errorf("%s: %s", ErrInvalidSeverity, s)
+		}
+		return p, nil
+	}
+	for p, name := range SeverityNames {
+		if name == s {
+			return p, nil
+		}
+	}
+	if p, ok := severityAliases[s]; ok {
+		return p, nil
+	}
+	return 0, fmt.Errorf("%s: %s", ErrInvalidSeverity, s)
+}
+
+// endregion: parse
+// region: ch
+
+// Severity is the current threshold of the channel, shared by the copies made by With()
+
+func (c *Ch) Severity() syslog.Priority {
+	if c.severity == nil {
+		return *c.Config.Severity
+	}
+	return syslog.Priority(atomic.LoadInt32(c.severity))
+}
+
+func (c *Ch) SetSeverity(p syslog.Priority) error {
+	if p < LOG_EMERG || p > LOG_DEBUG {
+		return ErrInvalidSeverity
+	}
+	if c.severity == nil {
+		return ErrInvalidLoggerOrChannel
+	}
+	atomic.StoreInt32(c.severity, int32(p))
+	return nil
+}
+
+// ResetSeverity goes back to ChConfig.Severity
+
+func (c *Ch) ResetSeverity() error {
+	return c.SetSeverity(*c.Config.Severity)
+}
+
+// endregion: ch
+// region: logger
+
+// Severity is the most verbose threshold among the channels, that is the least severe entry that still goes somewhere
+
+func (l *Logger) Severity() syslog.Priority {
+	severity := LOG_EMERG
+	for _, c := range l.Channels() {
+		if p := c.Severity(); p > severity {
+			severity = p
+		}
+	}
+	return severity
+}
+
+func (l *Logger) SetSeverity(p syslog.Priority) (e error) {
+	if p < LOG_EMERG || p > LOG_DEBUG {
+		return ErrInvalidSeverity
+	}
+	for _, c := range l.Channels() {
+		if err := c.SetSeverity(p); err != nil {
+			e = err
+		}
+	}
+	return e
+}
+
+func (l *Logger) ResetSeverity() (e error) {
+	for _, c := range l.Channels() {
+		if err := c.ResetSeverity(); err != nil {
+			e = err
+		}
+	}
+	return e
+}
+
+// endregion: logger
@@ -0,0 +1,385 @@
+// region: packages
+
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/syslog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// endregion: packages
+// region: types
+
+type PushFormat int
+
+// shipper batches the encoded entries of ChHTTP and posts them from its own goroutine
+
+type shipper struct {
+	dropped uint64 // 64-bit atomics first, for the sake of 32-bit platforms
+	failed  uint64
+
+	mu      sync.Mutex
+	buf     []shipItem
+	closed  bool
+	sending sync.Mutex // one batch at a time, in order
+
+	backoff    time.Duration
+	backoffMax time.Duration
+	batchSize  int
+	batchWait  time.Duration
+	bufferSize int
+	channel    string
+	client     *http.Client
+	format     PushFormat
+	gzip       bool
+	headers    http.Header
+	index      string
+	retries    int
+	tag        string
+	url        string
+
+	kick chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+type shipItem struct {
+	line     string
+	severity syslog.Priority
+	time     time.Time
+}
+
+// endregion: types
+// region: constants
+
+const (
+	PushElastic PushFormat = iota // _bulk NDJSON, ChConfig.URL is like http://localhost:9200/_bulk
+	PushLoki                      // push API JSON, ChConfig.URL is like http://localhost:3100/loki/api/v1/push
+)
+
+// endregion: constants
+// region: constructor
+
+func newShipper(c *Ch) (*shipper, error) {
+	if *c.Config.URL == "" {
+		return nil, fmt.Errorf("%s: %s", ErrInvalidNetwork, "missing url")
+	}
+	s := shipper{
+		backoff:    *c.Config.Backoff,
+		backoffMax: *c.Config.BackoffMax,
+		batchSize:  *c.Config.BatchSize,
+		batchWait:  *c.Config.BatchWait,
+		bufferSize: *c.Config.BufferSize,
+		channel:    c.Name(),
+		client:     &http.Client{Timeout: *c.Config.Timeout},
+		format:     *c.Config.PushFormat,
+		gzip:       *c.Config.Gzip,
+		headers:    c.Config.Headers,
+		index:      *c.Config.Index,
+		retries:    *c.Config.Retries,
+		tag:        *c.Config.Tag,
+		url:        *c.Config.URL,
+		kick:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	if s.batchSize < 1 {
+		s.batchSize = 1
+	}
+	if s.bufferSize < s.batchSize {
+		s.bufferSize = s.batchSize
+	}
+	if s.tag == "" {
+		s.tag = filepath.Base(os.Args[0])
+	}
+
+	s.wg.Add(1)
+	go s.run()
+	return &s, nil
+}
+
+// endregion: constructor
+// region: buffer
+
+// add never blocks, the oldest entries are dropped if the endpoint can't keep up
+
+func (s *shipper) add(severity syslog.Priority, t time.Time, line string) {
+	s.mu.Lock()
+	if len(s.buf) >= s.bufferSize {
+		s.buf = s.buf[1:]
+		atomic.AddUint64(&s.dropped, 1)
+	}
+	s.buf = append(s.buf, shipItem{line: line, severity: severity, time: t})
+	full := len(s.buf) >= s.batchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *shipper) take() []shipItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.buf)
+	if n > s.batchSize {
+		n = s.batchSize
+	}
+	batch := s.buf[:n:n]
+	s.buf = s.buf[n:]
+	return batch
+}
+
+func (s *shipper) pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buf)
+}
+
+func (s *shipper) run() {
+	defer s.wg.Done()
+	tick := time.NewTicker(s.batchWait)
+	defer tick.Stop()
+
+	// close() shouldn't wait for the retries of a batch on the way
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.done
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.kick:
+		case <-tick.C:
+		}
+		s.ship(ctx)
+	}
+}
+
+// ship sends batches until the buffer is empty, or until one of them can't be delivered even after the retries,
+// that one is counted as failed, the rest is left for the next round, there is no point in hammering a dead endpoint
+
+func (s *shipper) ship(ctx context.Context) error {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	for {
+		batch := s.take()
+		if len(batch) == 0 {
+			return nil
+		}
+		if e := s.send(ctx, batch); e != nil {
+			atomic.AddUint64(&s.failed, uint64(len(batch)))
+			return e
+		}
+	}
+}
+
+// discard drops what's left in the buffer
+
+func (s *shipper) discard() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	atomic.AddUint64(&s.dropped, uint64(len(s.buf)))
+	s.buf = nil
+}
+
+// endregion: buffer
+// region: send
+
+func (s *shipper) send(ctx context.Context, batch []shipItem) error {
+	body, e := s.payload(batch)
+	if e != nil {
+		return e
+	}
+	if s.gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(body)
+		zw.Close()
+		body = buf.Bytes()
+	}
+
+	wait := s.backoff
+	for attempt := 0; ; attempt++ {
+		retry, e := s.post(ctx, body)
+		if e == nil || !retry || attempt >= s.retries {
+			return e
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > s.backoffMax {
+			wait = s.backoffMax
+		}
+	}
+}
+
+// post tells if it's worth another try: network errors, 429 and 5xx are, the rest isn't
+
+func (s *shipper) post(ctx context.Context, body []byte) (bool, error) {
+	req, e := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if e != nil {
+		return false, e
+	}
+	for k, v := range s.headers {
+		req.Header[k] = v
+	}
+	if s.format == PushElastic {
+		req.Header.Set("Content-Type", "application/x-ndjson")
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, e := s.client.Do(req)
+	if e != nil {
+		return true, e
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, fmt.Errorf("%s: %s", ErrPushFailed, resp.Status)
+	}
+	if resp.StatusCode >= 300 {
+		return false, fmt.Errorf("%s: %s: %s", ErrPushFailed, resp.Status, bytes.TrimSpace(b))
+	}
+
+	// bulk says 200 even if some of the documents were rejected
+	if s.format == PushElastic {
+		var r struct {
+			Errors bool `json:"errors"`
+		}
+		if json.Unmarshal(b, &r) == nil && r.Errors {
+			return false, fmt.Errorf("%s: %s", ErrPushFailed, "bulk request has errors")
+		}
+	}
+	return false, nil
+}
+
+// endregion: send
+// region: payload
+
+func (s *shipper) payload(batch []shipItem) ([]byte, error) {
+	if s.format == PushLoki {
+		return s.payloadLoki(batch)
+	}
+	return s.payloadElastic(batch)
+}
+
+// each document is the entry as encoded if that's a json object, or the line wrapped in one otherwise
+
+func (s *shipper) payloadElastic(batch []shipItem) ([]byte, error) {
+	action, e := json.Marshal(map[string]interface{}{"index": map[string]string{"_index": s.index}})
+	if e != nil {
+		return nil, e
+	}
+
+	var buf bytes.Buffer
+	for _, item := range batch {
+		doc := bytes.TrimSpace([]byte(item.line))
+		if len(doc) == 0 || doc[0] != '{' || !json.Valid(doc) {
+			doc, _ = json.Marshal(map[string]interface{}{
+				"@timestamp": item.time.UTC().Format(time.RFC3339Nano),
+				"severity":   int(item.severity),
+				"label":      SeverityNames[item.severity],
+				"channel":    s.channel,
+				"msg":        item.line,
+			})
+		}
+		buf.Write(action)
+		buf.WriteByte('\n')
+		buf.Write(doc)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// one stream per severity, labelled with the tag, the channel and the severity
+
+func (s *shipper) payloadLoki(batch []shipItem) ([]byte, error) {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	streams := make([]*stream, 0)
+	bySeverity := make(map[syslog.Priority]*stream)
+	for _, item := range batch {
+		st, ok := bySeverity[item.severity]
+		if !ok {
+			st = &stream{Stream: map[string]string{
+				"job":     s.tag,
+				"channel": s.channel,
+				"level":   SeverityNames[item.severity],
+			}}
+			bySeverity[item.severity] = st
+			streams = append(streams, st)
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(item.time.UnixNano(), 10), item.line})
+	}
+	return json.Marshal(map[string]interface{}{"streams": streams})
+}
+
+// endregion: payload
+// region: flush and close
+
+func (s *shipper) flush(ctx context.Context) error {
+	return s.ship(ctx)
+}
+
+// close stops the timer and sends what's left, within the timeout of the channel altogether, what couldn't be sent by then is dropped.
+// Only the first call does anything.
+
+func (s *shipper) close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+	close(s.done)
+	s.wg.Wait()
+
+	ctx := context.Background()
+	if s.client.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.client.Timeout)
+		defer cancel()
+	}
+	e := s.ship(ctx)
+	s.discard()
+	return e
+}
+
+func (s *shipper) stats() QueueStats {
+	return QueueStats{
+		Dropped: atomic.LoadUint64(&s.dropped),
+		Failed:  atomic.LoadUint64(&s.failed),
+		Queued:  s.pending(),
+	}
+}
+
+// endregion: flush and close
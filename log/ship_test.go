@@ -0,0 +1,315 @@
+package log_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SandorMiskey/TEx-kit/log"
+)
+
+// pushServer records the requests and answers them with the statuses given, 200 once they run out
+
+type pushServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	bodies   [][]byte
+	headers  []http.Header
+	statuses []int
+}
+
+func newPushServer(t *testing.T, statuses ...int) *pushServer {
+	t.Helper()
+	s := pushServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, e := gzip.NewReader(r.Body)
+			if e != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = zr
+		}
+		b, _ := io.ReadAll(body)
+
+		s.mu.Lock()
+		s.bodies = append(s.bodies, b)
+		s.headers = append(s.headers, r.Header.Clone())
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return &s
+}
+
+func (s *pushServer) requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func newShip(t *testing.T, url string, c log.ChConfig) *log.Ch {
+	t.Helper()
+	wait := time.Hour // flushed by hand
+	backoff := time.Millisecond
+	c.Type = log.ChHTTP
+	c.URL = &url
+	c.BatchWait = &wait
+	c.Backoff = &backoff
+	c.BackoffMax = &backoff
+	ch, e := log.NewCh(c)
+	if e != nil {
+		t.Fatal(e)
+	}
+	t.Cleanup(func() { ch.Close() })
+	return ch
+}
+
+func TestShipElastic(t *testing.T) {
+	srv := newPushServer(t)
+	index := "idx"
+	ch := newShip(t, srv.URL, log.ChConfig{Index: &index, Encoder: &log.EncoderFlat})
+	jch := newShip(t, srv.URL, log.ChConfig{Index: &index})
+
+	ch.Out(log.LOG_ERR, "flat line")
+	jch.Out(log.LOG_INFO, "json line", log.F("k", "v"))
+	if e := ch.Flush(context.Background()); e != nil {
+		t.Fatal(e)
+	}
+	if e := jch.Flush(context.Background()); e != nil {
+		t.Fatal(e)
+	}
+
+	docs := make([]map[string]interface{}, 0)
+	for i, body := range srv.bodies {
+		if ct := srv.headers[i].Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("content type %q", ct)
+		}
+		if !bytes.HasSuffix(body, []byte("\n")) {
+			t.Errorf("bulk body doesn't end with a newline: %q", body)
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for n := 0; scanner.Scan(); n++ {
+			var m map[string]interface{}
+			if e := json.Unmarshal(scanner.Bytes(), &m); e != nil {
+				t.Fatalf("line %d: %s: %q", n, e, scanner.Text())
+			}
+			if n%2 == 0 {
+				if action, _ := m["index"].(map[string]interface{}); action["_index"] != "idx" {
+					t.Errorf("action line %q", scanner.Text())
+				}
+				continue
+			}
+			docs = append(docs, m)
+		}
+	}
+
+	var flat, structured map[string]interface{}
+	for _, doc := range docs {
+		if msg, _ := doc["msg"].(string); strings.Contains(msg, "flat line") {
+			flat = doc
+		}
+		if doc["k"] == "v" {
+			structured = doc
+		}
+	}
+	if flat == nil || flat["label"] != "err" || flat["severity"] != float64(log.LOG_ERR) || flat["@timestamp"] == nil {
+		t.Errorf("flat line is not wrapped: %v", flat)
+	}
+	if structured == nil || structured["@timestamp"] != nil {
+		t.Errorf("json line is not sent as it is: %v", structured)
+	}
+}
+
+func TestShipLoki(t *testing.T) {
+	srv := newPushServer(t)
+	format := log.PushLoki
+	gzipped := true
+	tag := "app"
+	ch := newShip(t, srv.URL, log.ChConfig{PushFormat: &format, Gzip: &gzipped, Tag: &tag})
+
+	ch.Out(log.LOG_ERR, "first err")
+	ch.Out(log.LOG_INFO, "info")
+	ch.Out(log.LOG_ERR, "second err")
+	if e := ch.Flush(context.Background()); e != nil {
+		t.Fatal(e)
+	}
+
+	if srv.requests() != 1 {
+		t.Fatalf("%d requests", srv.requests())
+	}
+	if h := srv.headers[0]; h.Get("Content-Type") != "application/json" || h.Get("Content-Encoding") != "gzip" {
+		t.Errorf("headers %v", h)
+	}
+	var push struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if e := json.Unmarshal(srv.bodies[0], &push); e != nil {
+		t.Fatal(e)
+	}
+	for _, st := range push.Streams {
+		if st.Stream["job"] != "app" || st.Stream["channel"] != ch.Name() {
+			t.Errorf("labels %v", st.Stream)
+		}
+		if st.Stream["level"] != "err" {
+			continue
+		}
+		if len(st.Values) != 2 || !strings.Contains(st.Values[0][1], "first err") || !strings.Contains(st.Values[1][1], "second err") {
+			t.Errorf("err stream %v", st.Values)
+		}
+		if st.Values[0][0] > st.Values[1][0] || len(st.Values[0][0]) < 19 {
+			t.Errorf("timestamps %s, %s", st.Values[0][0], st.Values[1][0])
+		}
+		return
+	}
+	t.Errorf("no err stream in %s", srv.bodies[0])
+}
+
+func TestShipRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		statuses []int
+		retries  int
+		requests int
+		failed   bool
+	}{
+		{"recovers", []int{503, 429}, 3, 3, false},
+		{"gives up", []int{500, 502, 429}, 2, 3, true},
+		{"client error", []int{400}, 3, 1, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newPushServer(t, tc.statuses...)
+			retries := tc.retries
+			ch := newShip(t, srv.URL, log.ChConfig{Retries: &retries})
+			e := ch.Flush(context.Background()) // the welcome
+
+			if srv.requests() != tc.requests {
+				t.Errorf("%d requests, want %d", srv.requests(), tc.requests)
+			}
+			if failed := e != nil; failed != tc.failed {
+				t.Errorf("error: %v", e)
+			}
+			if e != nil && !strings.HasPrefix(e.Error(), log.ErrPushFailed.Error()) {
+				t.Errorf("error: %v, want %v", e, log.ErrPushFailed)
+			}
+			if failed := ch.Stats().Failed; (failed > 0) != tc.failed {
+				t.Errorf("%d failed", failed)
+			}
+		})
+	}
+}
+
+func TestShipDrop(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	lines := make([]string, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+			<-release
+		default:
+		}
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		lines = append(lines, strings.Split(strings.TrimSpace(string(b)), "\n")...)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	size := 5
+	ch := newShip(t, srv.URL, log.ChConfig{BatchSize: &size, BufferSize: &size})
+
+	// the welcome and 4 more fill the first batch, which gets stuck at the server
+	for i := 0; i < 4; i++ {
+		ch.Out("first batch")
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first batch hasn't been sent")
+	}
+
+	// the buffer keeps the newest 5 of 10
+	for i := 0; i < 10; i++ {
+		ch.Out(fmt.Sprintf("entry-%d", i))
+	}
+	if stats := ch.Stats(); stats.Dropped != 5 || stats.Queued != 5 {
+		t.Errorf("stats %+v, want 5 dropped and 5 queued", stats)
+	}
+	close(release)
+	if e := ch.Flush(context.Background()); e != nil {
+		t.Fatal(e)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	got := strings.Join(lines, "\n")
+	for i := 0; i < 10; i++ {
+		if kept := strings.Contains(got, fmt.Sprintf("entry-%d", i)); kept != (i >= 5) {
+			t.Errorf("entry %d kept: %v", i, kept)
+		}
+	}
+}
+
+func TestShipCloseTwice(t *testing.T) {
+	srv := newPushServer(t)
+	ch, e := log.NewCh(log.ChConfig{Type: log.ChHTTP, URL: &srv.URL})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e := ch.Close(); e != nil {
+		t.Fatal(e)
+	}
+	if e := ch.Close(); e != nil {
+		t.Fatal(e)
+	}
+}
+
+func TestShipCloseDeadEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close() // connection refused from now on
+
+	batch := 1
+	backoff := time.Second
+	timeout := 300 * time.Millisecond
+	ch, e := log.NewCh(log.ChConfig{Type: log.ChHTTP, URL: &url, BatchSize: &batch, Backoff: &backoff, Timeout: &timeout})
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i := 0; i < 5; i++ {
+		ch.Out(log.LOG_INFO, "nobody listens")
+	}
+
+	start := time.Now()
+	if e := ch.Close(); e == nil {
+		t.Error("closed without an error")
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("close took %s", d)
+	}
+	stats := ch.Stats()
+	if stats.Queued != 0 || stats.Failed == 0 || stats.Dropped+stats.Failed != 7 { // welcome, 5 entries and bye
+		t.Errorf("stats %+v", stats)
+	}
+}
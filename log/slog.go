@@ -0,0 +1,171 @@
+// region: packages
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"log/syslog"
+	"runtime"
+	"time"
+)
+
+// endregion: packages
+// region: types
+
+type slogHandler struct {
+	l      *Logger
+	prefix string // groups so far, joined by dots
+}
+
+// endregion: types
+// region: levels
+
+// slog leaves room between its levels, priorities take the upper part of each gap
+
+var slogLevels = map[syslog.Priority]slog.Level{
+	LOG_EMERG:   slog.LevelError + 12,
+	LOG_ALERT:   slog.LevelError + 8,
+	LOG_CRIT:    slog.LevelError + 4,
+	LOG_ERR:     slog.LevelError,
+	LOG_WARNING: slog.LevelWarn,
+	LOG_NOTICE:  slog.LevelInfo + 2,
+	LOG_INFO:    slog.LevelInfo,
+	LOG_DEBUG:   slog.LevelDebug,
+}
+
+func SlogLevel(p syslog.Priority) slog.Level {
+	if level, ok := slogLevels[p]; ok {
+		return level
+	}
+	return slog.LevelDebug
+}
+
+// SlogPriority is the most severe priority whose level is not above l, eg. anything below slog.LevelInfo is LOG_DEBUG
+
+func SlogPriority(l slog.Level) syslog.Priority {
+	for p := LOG_EMERG; p < LOG_DEBUG; p++ {
+		if l >= slogLevels[p] {
+			return p
+		}
+	}
+	return LOG_DEBUG
+}
+
+// endregion: levels
+// region: handler
+
+// log/slog itself is in the way between the handler and the caller
+
+func init() {
+	RegisterHelper("log/slog")
+}
+
+// SlogHandler lets slog.New() write to the channels of the logger, attrs become fields, groups prefix their keys like "group.key"
+
+func (l *Logger) SlogHandler() slog.Handler {
+	l.lazy()
+	return &slogHandler{l: l}
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return SlogPriority(level) <= h.l.verbosity()
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	severity := SlogPriority(r.Level)
+	fields := make(Fields, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = slogFields(fields, h.prefix, a)
+		return true
+	})
+
+	entry := Entry{
+		Args:     []interface{}{r.Message},
+		Caller:   slogCaller(r.PC),
+		Fields:   joinFields(h.l.fields, fields),
+		Severity: &severity,
+		Time:     r.Time,
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if es := h.l.out(&entry, h.l.hooks.snapshot()); es != nil {
+		return (*es)[0]
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(Fields, 0, len(attrs))
+	for _, a := range attrs {
+		fields = slogFields(fields, h.prefix, a)
+	}
+	return &slogHandler{l: h.l.With(fields), prefix: h.prefix}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{l: h.l, prefix: h.prefix + name + "."}
+}
+
+// slogFields flattens groups, empty attrs and groups are left out as slog.Handler asks for
+
+func slogFields(fields Fields, prefix string, a slog.Attr) Fields {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		return append(fields, F(prefix+a.Key, a.Value.Any()))
+	}
+	if a.Key != "" {
+		prefix = prefix + a.Key + "."
+	}
+	for _, ga := range a.Value.Group() {
+		fields = slogFields(fields, prefix, ga)
+	}
+	return fields
+}
+
+func slogCaller(pc uintptr) Frame {
+	if pc == 0 {
+		frame, _ := caller(0)
+		return frame
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return Frame{File: frame.File, Function: frame.Function, Line: frame.Line, PC: pc}
+}
+
+// endregion: handler
+// region: channel
+
+// slogOut hands the entry to ChConfig.Slog as a record, fields become attrs, the encoder is not used
+
+func (c *Ch) slogOut() error {
+	entry := c.Entry()
+	severity := *c.Config.Severity
+	if entry.Severity != nil {
+		severity = *entry.Severity
+	}
+
+	ctx := context.Background()
+	level := SlogLevel(severity)
+	if !c.Config.Slog.Enabled(ctx, level) {
+		return nil
+	}
+
+	_, fields := splitFields(entry.Args)
+	r := slog.NewRecord(entry.Time, level, entry.Message(), entry.Caller.PC)
+	for _, f := range joinFields(entry.Fields, fields) {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	if len(entry.Stack) > 0 {
+		r.AddAttrs(slog.String("stack", entry.Stack.String()))
+	}
+	return c.Config.Slog.Handle(ctx, r)
+}
+
+// endregion: channel
@@ -0,0 +1,202 @@
+// region: packages
+
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// endregion: packages
+// region: types
+
+type Framing int
+
+// stream is the io.Writer behind ChNet, frames go to a spool file while the remote is down, and are replayed first thing after the next dial
+
+type stream struct {
+	mu sync.Mutex
+
+	conn      *netConn
+	framing   Framing
+	spool     *os.File
+	spoolName string
+	spoolPerm fs.FileMode
+	spoolSize int64
+	spooled   int64
+	timeout   time.Duration
+}
+
+// endregion: types
+// region: constants
+
+const (
+	FrameNewline Framing = iota // one entry per line
+	FrameLength                 // 4 bytes of length (big endian) before each entry, for multiline encoders
+)
+
+// endregion: constants
+// region: constructor
+
+func newStream(c *Ch) (*stream, error) {
+	conn, e := newNetConn(c)
+	if e != nil {
+		return nil, e
+	}
+
+	s := stream{
+		conn:      conn,
+		framing:   *c.Config.Framing,
+		spoolName: *c.Config.Spool,
+		spoolPerm: fs.FileMode(*c.Config.FilePerm),
+		spoolSize: *c.Config.SpoolSize,
+		timeout:   *c.Config.Timeout,
+	}
+	conn.connect = s.replay
+
+	// whatever was left in the spool by the last run goes first
+	if s.spoolName != "" {
+		f, e := os.OpenFile(s.spoolName, os.O_CREATE|os.O_RDWR|os.O_APPEND, s.spoolPerm)
+		if e != nil {
+			return nil, e
+		}
+		info, e := f.Stat()
+		if e != nil {
+			f.Close()
+			return nil, e
+		}
+		s.spool = f
+		s.spooled = info.Size()
+	}
+
+	// a wrong address is only fatal if there is no spool to fall back on
+	if e := conn.dialNow(); e != nil && s.spool == nil {
+		return nil, e
+	}
+	return &s, nil
+}
+
+// endregion: constructor
+// region: write
+
+// Write gets complete lines from Ch.output()
+
+func (s *stream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	frame := s.frame(p)
+	if _, e := s.conn.Write(frame); e != nil {
+		if s.spool == nil {
+			return 0, e
+		}
+		if e := s.park(frame); e != nil {
+			return 0, e
+		}
+	}
+	return len(p), nil
+}
+
+func (s *stream) frame(p []byte) []byte {
+	if s.framing != FrameLength {
+		return p
+	}
+	p = bytes.TrimSuffix(p, []byte("\n"))
+	frame := make([]byte, 4, 4+len(p))
+	binary.BigEndian.PutUint32(frame, uint32(len(p)))
+	return append(frame, p...)
+}
+
+// caller holds s.mu
+
+func (s *stream) park(frame []byte) error {
+	if s.spoolSize > 0 && s.spooled+int64(len(frame)) > s.spoolSize {
+		return fmt.Errorf("%s: %s", ErrSpoolFull, s.spoolName)
+	}
+	n, e := s.spool.Write(frame)
+	s.spooled += int64(n)
+	return e
+}
+
+// replay is the connect hook of the netConn, the caller holds s.mu (and conn.mu), the spool is only emptied if all of it went through
+
+func (s *stream) replay(conn net.Conn) error {
+	if s.spool == nil || s.spooled == 0 {
+		return nil
+	}
+	if _, e := s.spool.Seek(0, io.SeekStart); e != nil {
+		return e
+	}
+	if s.timeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	}
+
+	// datagrams need one write per frame, streams don't care
+	var e error
+	if s.conn.stream() {
+		_, e = io.Copy(conn, s.spool)
+	} else {
+		e = s.frames(func(frame []byte) error {
+			_, e := conn.Write(frame)
+			return e
+		})
+	}
+	if e != nil {
+		return e
+	}
+	if e := s.spool.Truncate(0); e != nil {
+		return e
+	}
+	s.spooled = 0
+	return nil
+}
+
+// frames reads the spool back frame by frame
+
+func (s *stream) frames(f func(frame []byte) error) error {
+	b, e := io.ReadAll(s.spool)
+	if e != nil {
+		return e
+	}
+	for len(b) > 0 {
+		n := bytes.IndexByte(b, '\n') + 1
+		if s.framing == FrameLength && len(b) >= 4 {
+			n = 4 + int(binary.BigEndian.Uint32(b))
+		}
+		if n <= 0 || n > len(b) {
+			n = len(b)
+		}
+		if e := f(b[:n]); e != nil {
+			return e
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// endregion: write
+// region: close
+
+// close makes a last attempt to deliver the spool, what's left stays there for the next run
+
+func (s *stream) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.spool != nil {
+		if s.spooled > 0 {
+			s.conn.dialNow()
+		}
+		s.spool.Close()
+	}
+	return s.conn.Close()
+}
+
+// endregion: close
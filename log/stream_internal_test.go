@@ -0,0 +1,43 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// frames is what replays the spool over datagrams, one write per frame
+
+func TestStreamFrames(t *testing.T) {
+	for _, framing := range []Framing{FrameNewline, FrameLength} {
+		f, e := os.Create(filepath.Join(t.TempDir(), "spool"))
+		if e != nil {
+			t.Fatal(e)
+		}
+		defer f.Close()
+		s := stream{framing: framing, spool: f}
+
+		want := make([]string, 0)
+		for _, entry := range []string{"one\n", "two\n", "three\n"} {
+			if framing == FrameLength {
+				entry = "multi\nline\n" + entry
+			}
+			frame := s.frame([]byte(entry))
+			f.Write(frame)
+			want = append(want, string(frame))
+		}
+		f.Seek(0, 0)
+
+		got := make([]string, 0)
+		if e := s.frames(func(frame []byte) error {
+			got = append(got, string(frame))
+			return nil
+		}); e != nil {
+			t.Fatal(e)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("framing %d: got %q, want %q", framing, got, want)
+		}
+	}
+}
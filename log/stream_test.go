@@ -0,0 +1,156 @@
+package log_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SandorMiskey/TEx-kit/log"
+)
+
+func newStream(t *testing.T, addr string, c log.ChConfig) *log.Ch {
+	t.Helper()
+	network := "tcp"
+	backoff := 10 * time.Millisecond
+	c.Type = log.ChNet
+	c.Addr = &addr
+	c.Network = &network
+	c.Backoff = &backoff
+	c.BackoffMax = &backoff
+	ch, e := log.NewCh(c)
+	if e != nil {
+		t.Fatal(e)
+	}
+	t.Cleanup(func() { ch.Close() })
+	return ch
+}
+
+// deadAddr is a local address nobody listens on (for now)
+
+func deadAddr(t *testing.T) string {
+	t.Helper()
+	ln, e := net.Listen("tcp", "127.0.0.1:0")
+	if e != nil {
+		t.Fatal(e)
+	}
+	ln.Close()
+	return ln.Addr().String()
+}
+
+func accept(t *testing.T, ln net.Listener) net.Conn {
+	t.Helper()
+	ln.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+	conn, e := ln.Accept()
+	if e != nil {
+		t.Fatal(e)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestStreamReplay(t *testing.T) {
+	addr := deadAddr(t)
+	spool := filepath.Join(t.TempDir(), "net.spool")
+	framing := log.FrameLength
+	ch := newStream(t, addr, log.ChConfig{Spool: &spool, Framing: &framing})
+
+	// the peer is down, everything goes to the spool
+	want := []string{"welcome"}
+	for _, m := range []string{"first", "second\nwith two lines", "third"} {
+		if e := ch.Out(log.LOG_INFO, m); e != nil {
+			t.Fatal(e)
+		}
+		want = append(want, m)
+	}
+	if info, e := os.Stat(spool); e != nil || info.Size() == 0 {
+		t.Fatalf("nothing spooled: %v", e)
+	}
+
+	// it comes back, the next entry is preceded by the spool
+	ln, e := net.Listen("tcp", addr)
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer ln.Close()
+	time.Sleep(20 * time.Millisecond) // past the backoff
+	if e := ch.Out(log.LOG_INFO, "after"); e != nil {
+		t.Fatal(e)
+	}
+	want = append(want, "after")
+
+	conn := accept(t, ln)
+	for i, w := range want {
+		var size uint32
+		if e := binary.Read(conn, binary.BigEndian, &size); e != nil {
+			t.Fatalf("frame %d: %s", i, e)
+		}
+		frame := make([]byte, size)
+		if _, e := io.ReadFull(conn, frame); e != nil {
+			t.Fatalf("frame %d: %s", i, e)
+		}
+		if i == 0 {
+			continue // the welcome, whatever it says
+		}
+		if !strings.HasSuffix(string(frame), w) {
+			t.Errorf("frame %d: %q, want %q", i, frame, w)
+		}
+	}
+	if info, _ := os.Stat(spool); info.Size() != 0 {
+		t.Errorf("%d bytes left in the spool", info.Size())
+	}
+}
+
+func TestStreamSpoolFull(t *testing.T) {
+	spool := filepath.Join(t.TempDir(), "net.spool")
+	size := int64(256)
+	ch := newStream(t, deadAddr(t), log.ChConfig{Spool: &spool, SpoolSize: &size})
+
+	var e error
+	for i := 0; i < 100 && e == nil; i++ {
+		e = ch.Out(log.LOG_INFO, "filling up the spool")
+	}
+	if e == nil || !strings.HasPrefix(e.Error(), log.ErrSpoolFull.Error()) {
+		t.Fatalf("error: %v, want %v", e, log.ErrSpoolFull)
+	}
+	if info, _ := os.Stat(spool); info.Size() > size {
+		t.Errorf("spool is %d bytes, more than %d", info.Size(), size)
+	}
+}
+
+func TestStreamSpoolFromLastRun(t *testing.T) {
+	spool := filepath.Join(t.TempDir(), "net.spool")
+	if e := os.WriteFile(spool, []byte("left 1\nleft 2\n"), 0o600); e != nil {
+		t.Fatal(e)
+	}
+	ln, e := net.Listen("tcp", "127.0.0.1:0")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer ln.Close()
+
+	ch := newStream(t, ln.Addr().String(), log.ChConfig{Spool: &spool})
+	ch.Out(log.LOG_INFO, "new")
+
+	r := bufio.NewReader(accept(t, ln))
+	got := make([]string, 0)
+	for len(got) < 4 {
+		line, e := r.ReadString('\n')
+		if e != nil {
+			t.Fatalf("after %q: %s", got, e)
+		}
+		got = append(got, strings.TrimSuffix(line, "\n"))
+	}
+	if got[0] != "left 1" || got[1] != "left 2" || !strings.HasSuffix(got[3], "new") {
+		t.Errorf("got %q, want the spool, the welcome and the new entry", got)
+	}
+	if info, _ := os.Stat(spool); info.Size() != 0 {
+		t.Errorf("%d bytes left in the spool", info.Size())
+	}
+}
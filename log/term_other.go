@@ -0,0 +1,20 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+// region: packages
+
+package log
+
+import (
+	"os"
+)
+
+// endregion: packages
+// region: terminal
+
+// terminalWidth doesn't know how to tell here, so EncoderConsole sticks to plain text
+
+func terminalWidth(f *os.File) (width int, ok bool) {
+	return 0, false
+}
+
+// endregion: terminal
@@ -0,0 +1,31 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+// region: packages
+
+package log
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// endregion: packages
+// region: terminal
+
+// terminalWidth asks the terminal behind f for its size, ok is false if f is not a terminal
+
+func terminalWidth(f *os.File) (width int, ok bool) {
+	var ws struct {
+		Row    uint16
+		Col    uint16
+		Xpixel uint16
+		Ypixel uint16
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws))); errno != 0 {
+		return 0, false
+	}
+	return int(ws.Col), true
+}
+
+// endregion: terminal
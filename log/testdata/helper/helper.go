@@ -0,0 +1,13 @@
+// Package helper logs on behalf of its callers, like db does, for the tests of log
+
+package helper
+
+import "github.com/SandorMiskey/TEx-kit/log"
+
+func init() {
+	log.HelperPackage()
+}
+
+func Out(c *log.Ch, n ...interface{}) error {
+	return c.Out(n...)
+}
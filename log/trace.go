@@ -3,7 +3,10 @@
 package log
 
 import (
+	"fmt"
 	"runtime"
+	"strings"
+	"sync"
 )
 
 // endregion: packages
@@ -14,6 +17,7 @@ type Frame struct {
 	Function string
 	Line     int
 	More     bool
+	PC       uintptr
 }
 
 type Frames []Frame
@@ -53,6 +57,7 @@ func Trace(i ...int) Frames {
 			Function: frame.Function,
 			Line:     frame.Line,
 			More:     more,
+			PC:       frame.PC,
 		})
 
 		// check whether there are more frames to process after this one
@@ -64,3 +69,128 @@ func Trace(i ...int) Frames {
 }
 
 // endregion: trace
+// region: helpers, frames to be skipped when looking for the caller
+
+var helpers = struct {
+	sync.RWMutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+var self = packageOf(Trace(1)[0].Function)
+
+// RegisterHelper marks functions (eg. "main.logf") or whole packages (eg. "github.com/SandorMiskey/TEx-kit/db") as log dispatchers, entries will show their callers as the source
+
+func RegisterHelper(names ...string) {
+	helpers.Lock()
+	defer helpers.Unlock()
+	for _, name := range names {
+		helpers.names[name] = true
+	}
+}
+
+// Helper marks the calling function as a helper, like testing.T.Helper() does
+
+func Helper() {
+	if frames := Trace(3, 1); len(frames) > 0 {
+		RegisterHelper(frames[0].Function)
+	}
+}
+
+// HelperPackage marks the package of the calling function as a helper, handy in init()
+
+func HelperPackage() {
+	if frames := Trace(3, 1); len(frames) > 0 {
+		RegisterHelper(packageOf(frames[0].Function))
+	}
+}
+
+func isHelper(function string) bool {
+	if function == "" || strings.HasPrefix(function, "runtime.") {
+		return true
+	}
+	pkg := packageOf(function)
+	if pkg == self {
+		return true
+	}
+
+	helpers.RLock()
+	defer helpers.RUnlock()
+	if helpers.names[pkg] {
+		return true
+	}
+	// the function itself, or a closure or method value of it
+	for name := strings.TrimPrefix(function, pkg); ; {
+		if helpers.names[pkg+name] {
+			return true
+		}
+		i := strings.LastIndex(name, ".")
+		if i <= 0 {
+			return false
+		}
+		name = name[:i]
+	}
+}
+
+// packageOf strips the function name, eg. github.com/foo/bar.(*Baz).Qux -> github.com/foo/bar
+
+func packageOf(function string) string {
+	slash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
+
+// caller is the first frame outside of the helpers, skip counts additional frames above that. origin is the frame right before it,
+// the helper (or the skipped function) the caller called into, eg. db.Exec(), the caller itself if it called this package directly
+
+func caller(skip int) (Frame, Frame) {
+	var origin Frame
+	for _, frame := range Trace(3, 64) {
+		if frame.Function == "" || strings.HasPrefix(frame.Function, "runtime.") || packageOf(frame.Function) == self {
+			continue
+		}
+		if !isHelper(frame.Function) {
+			if skip == 0 {
+				if origin.Function == "" {
+					origin = frame
+				}
+				return frame, origin
+			}
+			skip--
+		}
+		origin = frame
+	}
+	return Frame{}, Frame{}
+}
+
+// endregion: helpers
+// region: stack
+
+// stack is the trace of the caller, without the frames of the runtime and this package
+
+func stack() Frames {
+	frames := make(Frames, 0)
+	for _, frame := range Trace(3, 64) {
+		if frame.Function == "" || strings.HasPrefix(frame.Function, "runtime.") || packageOf(frame.Function) == self {
+			continue
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// String renders the frames the way a panic would, one indented function and file:line pair per frame
+
+func (frames Frames) String() string {
+	var b strings.Builder
+	for i, frame := range frames {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "\t%s\n\t\t%s:%d", frame.Function, frame.File, frame.Line)
+	}
+	return b.String()
+}
+
+// endregion: stack
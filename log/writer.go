@@ -0,0 +1,96 @@
+// region: packages
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"log/syslog"
+	"sync"
+)
+
+// endregion: packages
+// region: types
+
+type lineWriter struct {
+	mu  sync.Mutex
+	buf []byte
+	out func(line string) error
+}
+
+// endregion: types
+// region: constants
+
+// longer lines are cut, rather than held back forever
+const lineMax = 64 << 10
+
+// endregion: constants
+// region: writer
+
+// the stdlib logger and fmt are in the way between the writer and whoever wrote the line
+
+func init() {
+	RegisterHelper("log", "fmt")
+}
+
+// Write sends every complete line through out, the rest waits for the next call
+
+func (w *lineWriter) Write(p []byte) (n int, e error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i, next := bytes.IndexByte(w.buf, '\n'), 0
+		switch {
+		case i >= 0:
+			next = i + 1
+		case len(w.buf) >= lineMax:
+			i, next = lineMax, lineMax
+		}
+		if next == 0 {
+			break
+		}
+		line := bytes.TrimRight(w.buf[:i], "\r")
+		if len(line) > 0 {
+			if err := w.out(string(line)); err != nil {
+				e = err
+			}
+		}
+		w.buf = w.buf[next:]
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), e
+}
+
+// Writer turns every line written to it into an entry at severity p, eg. for http.Server.ErrorLog or the mysql driver
+
+func (c *Ch) Writer(p syslog.Priority) io.Writer {
+	return &lineWriter{out: func(line string) error {
+		return c.Out(p, line)
+	}}
+}
+
+func (l *Logger) Writer(p syslog.Priority) io.Writer {
+	return &lineWriter{out: func(line string) error {
+		if es := l.Out(p, line); es != nil {
+			return (*es)[0]
+		}
+		return nil
+	}}
+}
+
+// StdLogger is a *log.Logger on top of Writer(), without prefix and flags since the channels have their own
+
+func (c *Ch) StdLogger(p syslog.Priority) *log.Logger {
+	return log.New(c.Writer(p), "", 0)
+}
+
+func (l *Logger) StdLogger(p syslog.Priority) *log.Logger {
+	return log.New(l.Writer(p), "", 0)
+}
+
+// endregion: writer